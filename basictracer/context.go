@@ -0,0 +1,64 @@
+package basictracer
+
+import "strconv"
+
+// SpanContext holds the basic Span metadata.
+type SpanContext struct {
+	// TraceIDHigh holds the upper 64 bits of a 128-bit trace ID. It is zero
+	// for 64-bit trace IDs.
+	TraceIDHigh uint64
+
+	// TraceID holds the lower (or only) 64 bits of the trace ID.
+	TraceID uint64
+
+	// SpanID identifies a span within its trace.
+	SpanID uint64
+
+	// Whether the trace is sampled.
+	Sampled bool
+
+	// The span's associated baggage.
+	Baggage map[string]string // initialized on first use
+}
+
+// ForeachBaggageItem belongs to the opentracing.SpanContext interface
+func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.Baggage {
+		if !handler(k, v) {
+			break
+		}
+	}
+}
+
+// WithBaggageItem returns an entirely new basictracer SpanContext with the
+// given key:value baggage pair set.
+func (c SpanContext) WithBaggageItem(key, val string) SpanContext {
+	var newBaggage map[string]string
+	if c.Baggage == nil {
+		newBaggage = map[string]string{key: val}
+	} else {
+		newBaggage = make(map[string]string, len(c.Baggage)+1)
+		for k, v := range c.Baggage {
+			newBaggage[k] = v
+		}
+		newBaggage[key] = val
+	}
+	// Use positional parameters so the compiler will help catch new fields.
+	return SpanContext{c.TraceIDHigh, c.TraceID, c.SpanID, c.Sampled, newBaggage}
+}
+
+// Is128Bit reports whether the context carries a 128-bit trace ID.
+func (c SpanContext) Is128Bit() bool {
+	return c.TraceIDHigh != 0
+}
+
+// TraceIDString returns the trace ID in hex, as propagated in text formats:
+// 32 zero-padded digits for 128-bit trace IDs.
+func (c SpanContext) TraceIDString() string {
+	return formatTraceID(c)
+}
+
+// SpanIDString returns the span ID in hex, as propagated in text formats.
+func (c SpanContext) SpanIDString() string {
+	return strconv.FormatUint(c.SpanID, 16)
+}
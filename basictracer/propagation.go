@@ -0,0 +1,310 @@
+package basictracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	prefixTracerState = "ot-tracer-"
+	prefixBaggage     = "ot-baggage-"
+
+	tracerStateFieldCount = 3
+	fieldNameTraceID      = prefixTracerState + "traceid"
+	fieldNameSpanID       = prefixTracerState + "spanid"
+	fieldNameSampled      = prefixTracerState + "sampled"
+
+	// binaryVersion is the first byte of every Binary-encoded SpanContext.
+	binaryVersion byte = 1
+
+	// binaryFlagSampled and binaryFlag128Bit are bits in the flags byte.
+	binaryFlagSampled byte = 1 << 0
+	binaryFlag128Bit  byte = 1 << 1
+
+	// binaryMaxLen bounds the length of any single length-prefixed field so
+	// that corrupted input can't trigger huge allocations.
+	binaryMaxLen = 1 << 20
+)
+
+type textMapPropagator struct {
+	httpHeaders bool
+}
+
+type binaryPropagator struct{}
+
+var (
+	textPropagator = &textMapPropagator{}
+	httpPropagator = &textMapPropagator{httpHeaders: true}
+	binPropagator  = &binaryPropagator{}
+
+	binaryBufPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+)
+
+func formatTraceID(sc SpanContext) string {
+	if sc.TraceIDHigh != 0 {
+		return formatHex(sc.TraceIDHigh) + formatHex(sc.TraceID)
+	}
+	return strconv.FormatUint(sc.TraceID, 16)
+}
+
+// formatHex formats v as exactly 16 lower-case hex digits.
+func formatHex(v uint64) string {
+	const zeros = "0000000000000000"
+	s := strconv.FormatUint(v, 16)
+	return zeros[len(s):] + s
+}
+
+func parseTraceID(s string) (high, low uint64, err error) {
+	if len(s) > 32 {
+		return 0, 0, opentracing.ErrSpanContextCorrupted
+	}
+	if len(s) > 16 {
+		if high, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, 0, opentracing.ErrSpanContextCorrupted
+		}
+		s = s[len(s)-16:]
+	}
+	if low, err = strconv.ParseUint(s, 16, 64); err != nil {
+		return 0, 0, opentracing.ErrSpanContextCorrupted
+	}
+	return high, low, nil
+}
+
+func (p *textMapPropagator) Inject(sc SpanContext, opaqueCarrier interface{}) error {
+	carrier, ok := opaqueCarrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	carrier.Set(fieldNameTraceID, formatTraceID(sc))
+	carrier.Set(fieldNameSpanID, strconv.FormatUint(sc.SpanID, 16))
+	carrier.Set(fieldNameSampled, strconv.FormatBool(sc.Sampled))
+
+	for k, v := range sc.Baggage {
+		if p.httpHeaders {
+			v = url.QueryEscape(v)
+		}
+		carrier.Set(prefixBaggage+k, v)
+	}
+	return nil
+}
+
+func (p *textMapPropagator) Extract(opaqueCarrier interface{}) (SpanContext, error) {
+	carrier, ok := opaqueCarrier.(opentracing.TextMapReader)
+	if !ok {
+		return SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+	requiredFieldCount := 0
+	var traceIDHigh, traceID, spanID uint64
+	var sampled bool
+	var err error
+	decodedBaggage := make(map[string]string)
+	err = carrier.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case fieldNameTraceID:
+			traceIDHigh, traceID, err = parseTraceID(v)
+			if err != nil {
+				return err
+			}
+		case fieldNameSpanID:
+			spanID, err = strconv.ParseUint(v, 16, 64)
+			if err != nil {
+				return opentracing.ErrSpanContextCorrupted
+			}
+		case fieldNameSampled:
+			sampled, err = strconv.ParseBool(v)
+			if err != nil {
+				return opentracing.ErrSpanContextCorrupted
+			}
+		default:
+			lowercaseK := strings.ToLower(k)
+			if strings.HasPrefix(lowercaseK, prefixBaggage) {
+				if p.httpHeaders {
+					// unescape errors are ignored, nothing can be done
+					if rawVal, err := url.QueryUnescape(v); err == nil {
+						v = rawVal
+					}
+				}
+				decodedBaggage[strings.TrimPrefix(lowercaseK, prefixBaggage)] = v
+			}
+			// Balance off the requiredFieldCount++ just below...
+			requiredFieldCount--
+		}
+		requiredFieldCount++
+		return nil
+	})
+	if err != nil {
+		return SpanContext{}, err
+	}
+	if requiredFieldCount < tracerStateFieldCount {
+		if requiredFieldCount == 0 {
+			return SpanContext{}, opentracing.ErrSpanContextNotFound
+		}
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	if (traceIDHigh == 0 && traceID == 0) || spanID == 0 {
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	if len(decodedBaggage) == 0 {
+		decodedBaggage = nil
+	}
+	return SpanContext{
+		TraceIDHigh: traceIDHigh,
+		TraceID:     traceID,
+		SpanID:      spanID,
+		Sampled:     sampled,
+		Baggage:     decodedBaggage,
+	}, nil
+}
+
+// Inject writes sc to an io.Writer carrier. The wire layout (all integers
+// big-endian) is:
+//
+//     version      uint8   (currently 1)
+//     flags        uint8   (bit 0: sampled, bit 1: 128-bit trace ID)
+//     traceIDHigh  uint64  (only present if the 128-bit flag is set)
+//     traceID      uint64
+//     spanID       uint64
+//     numBaggage   uint32
+//     numBaggage times:
+//         keyLen   uint32
+//         key      [keyLen]byte
+//         valLen   uint32
+//         val      [valLen]byte
+func (p *binaryPropagator) Inject(sc SpanContext, opaqueCarrier interface{}) error {
+	carrier, ok := opaqueCarrier.(io.Writer)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	buf := binaryBufPool.Get().(*bytes.Buffer)
+	defer binaryBufPool.Put(buf)
+	buf.Reset()
+
+	var flags byte
+	if sc.Sampled {
+		flags |= binaryFlagSampled
+	}
+	if sc.TraceIDHigh != 0 {
+		flags |= binaryFlag128Bit
+	}
+	buf.WriteByte(binaryVersion)
+	buf.WriteByte(flags)
+	var scratch [8]byte
+	if sc.TraceIDHigh != 0 {
+		binary.BigEndian.PutUint64(scratch[:], sc.TraceIDHigh)
+		buf.Write(scratch[:])
+	}
+	binary.BigEndian.PutUint64(scratch[:], sc.TraceID)
+	buf.Write(scratch[:])
+	binary.BigEndian.PutUint64(scratch[:], sc.SpanID)
+	buf.Write(scratch[:])
+	binary.BigEndian.PutUint32(scratch[:4], uint32(len(sc.Baggage)))
+	buf.Write(scratch[:4])
+	for k, v := range sc.Baggage {
+		binary.BigEndian.PutUint32(scratch[:4], uint32(len(k)))
+		buf.Write(scratch[:4])
+		buf.WriteString(k)
+		binary.BigEndian.PutUint32(scratch[:4], uint32(len(v)))
+		buf.Write(scratch[:4])
+		buf.WriteString(v)
+	}
+	_, err := carrier.Write(buf.Bytes())
+	return err
+}
+
+func (p *binaryPropagator) Extract(opaqueCarrier interface{}) (SpanContext, error) {
+	carrier, ok := opaqueCarrier.(io.Reader)
+	if !ok {
+		return SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+	var header [2]byte
+	if n, err := io.ReadFull(carrier, header[:]); err != nil {
+		if n == 0 && err == io.EOF {
+			return SpanContext{}, opentracing.ErrSpanContextNotFound
+		}
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	if header[0] != binaryVersion {
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	flags := header[1]
+
+	var sc SpanContext
+	sc.Sampled = flags&binaryFlagSampled != 0
+	var err error
+	if flags&binaryFlag128Bit != 0 {
+		if sc.TraceIDHigh, err = readUint64(carrier); err != nil {
+			return SpanContext{}, err
+		}
+	}
+	if sc.TraceID, err = readUint64(carrier); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.SpanID, err = readUint64(carrier); err != nil {
+		return SpanContext{}, err
+	}
+	if (sc.TraceIDHigh == 0 && sc.TraceID == 0) || sc.SpanID == 0 {
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	numBaggage, err := readUint32(carrier)
+	if err != nil {
+		return SpanContext{}, err
+	}
+	if numBaggage > binaryMaxLen {
+		return SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	if numBaggage > 0 {
+		sc.Baggage = make(map[string]string, numBaggage)
+	}
+	for i := uint32(0); i < numBaggage; i++ {
+		k, err := readString(carrier)
+		if err != nil {
+			return SpanContext{}, err
+		}
+		v, err := readString(carrier)
+		if err != nil {
+			return SpanContext{}, err
+		}
+		sc.Baggage[k] = v
+	}
+	return sc, nil
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, opentracing.ErrSpanContextCorrupted
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, opentracing.ErrSpanContextCorrupted
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+func readString(r io.Reader) (string, error) {
+	n, err := readUint32(r)
+	if err != nil {
+		return "", err
+	}
+	if n > binaryMaxLen {
+		return "", opentracing.ErrSpanContextCorrupted
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", opentracing.ErrSpanContextCorrupted
+	}
+	return string(b), nil
+}
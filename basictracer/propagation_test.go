@@ -0,0 +1,124 @@
+package basictracer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+func TestPropagation_RoundTrip(t *testing.T) {
+	for _, use128Bit := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.Recorder = NewInMemoryRecorder()
+		opts.TraceID128Bit = use128Bit
+		tracer := NewWithOptions(opts)
+
+		tests := []struct {
+			format  opentracing.BuiltinFormat
+			carrier interface{}
+		}{
+			{opentracing.TextMap, opentracing.TextMapCarrier{}},
+			{opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{})},
+			{opentracing.Binary, new(bytes.Buffer)},
+		}
+		for _, test := range tests {
+			span := tracer.StartSpan("x")
+			span.SetBaggageItem("checked", "baggage:value")
+			sc := span.Context().(SpanContext)
+
+			require.NoError(t, tracer.Inject(sc, test.format, test.carrier))
+			extracted, err := tracer.Extract(test.format, test.carrier)
+			require.NoError(t, err)
+			assert.Equal(t, sc, extracted, "format %v, 128-bit %v", test.format, use128Bit)
+		}
+	}
+}
+
+func TestPropagation_HTTPHeadersEscapeBaggage(t *testing.T) {
+	tracer := New(NewInMemoryRecorder())
+	span := tracer.StartSpan("x")
+	span.SetBaggageItem("x", "y:z")
+
+	carrier := opentracing.HTTPHeadersCarrier(http.Header{})
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders, carrier))
+	assert.Equal(t, "y%3Az", carrier["Ot-Baggage-X"][0])
+}
+
+func TestPropagation_TextMapErrors(t *testing.T) {
+	tracer := New(NewInMemoryRecorder())
+	tests := []struct {
+		carrier opentracing.TextMapCarrier
+		err     error
+	}{
+		{opentracing.TextMapCarrier{}, opentracing.ErrSpanContextNotFound},
+		{opentracing.TextMapCarrier{"unrelated": "x"}, opentracing.ErrSpanContextNotFound},
+		{opentracing.TextMapCarrier{fieldNameTraceID: "1"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			fieldNameTraceID: "xyz", fieldNameSpanID: "1", fieldNameSampled: "true",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			fieldNameTraceID: "1", fieldNameSpanID: "1", fieldNameSampled: "maybe",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			fieldNameTraceID: "123456789012345678901234567890123", fieldNameSpanID: "1", fieldNameSampled: "true",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			fieldNameTraceID: "0", fieldNameSpanID: "1", fieldNameSampled: "true",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			fieldNameTraceID: "1", fieldNameSpanID: "0", fieldNameSampled: "true",
+		}, opentracing.ErrSpanContextCorrupted},
+	}
+	for _, test := range tests {
+		sc, err := tracer.Extract(opentracing.TextMap, test.carrier)
+		assert.Equal(t, test.err, err, "%v", test.carrier)
+		assert.Nil(t, sc)
+	}
+}
+
+func TestPropagation_BinaryErrors(t *testing.T) {
+	tracer := New(NewInMemoryRecorder())
+	span := tracer.StartSpan("x")
+	span.SetBaggageItem("k", "v")
+	buf := new(bytes.Buffer)
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.Binary, buf))
+	encoded := buf.Bytes()
+
+	badVersion := append([]byte{}, encoded...)
+	badVersion[0] = 0
+
+	zeroIDs := new(bytes.Buffer)
+	require.NoError(t, tracer.Inject(SpanContext{Sampled: true}, opentracing.Binary, zeroIDs))
+	zeroSpanID := new(bytes.Buffer)
+	require.NoError(t, tracer.Inject(SpanContext{TraceID: 1}, opentracing.Binary, zeroSpanID))
+
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, opentracing.ErrSpanContextNotFound},
+		{encoded[:1], opentracing.ErrSpanContextCorrupted},
+		{encoded[:10], opentracing.ErrSpanContextCorrupted},
+		{encoded[:len(encoded)-1], opentracing.ErrSpanContextCorrupted},
+		{badVersion, opentracing.ErrSpanContextCorrupted},
+		{zeroIDs.Bytes(), opentracing.ErrSpanContextCorrupted},
+		{zeroSpanID.Bytes(), opentracing.ErrSpanContextCorrupted},
+	}
+	for _, test := range tests {
+		sc, err := tracer.Extract(opentracing.Binary, bytes.NewReader(test.data))
+		assert.Equal(t, test.err, err, "%v", test.data)
+		assert.Nil(t, sc)
+	}
+}
+
+func TestSpanContext_IDStrings(t *testing.T) {
+	sc := SpanContext{TraceIDHigh: 1, TraceID: 2, SpanID: 0xab}
+	assert.Equal(t, "00000000000000010000000000000002", sc.TraceIDString())
+	assert.Equal(t, "ab", sc.SpanIDString())
+	assert.Equal(t, "2", SpanContext{TraceID: 2}.TraceIDString())
+}
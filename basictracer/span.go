@@ -0,0 +1,200 @@
+package basictracer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Implements the `opentracing.Span` interface. Created via Tracer.StartSpan.
+type spanImpl struct {
+	tracer     *Tracer
+	sync.Mutex // protects the fields below
+	raw        RawSpan
+	// The number of logs dropped because of MaxLogsPerSpan.
+	numDroppedLogs int
+}
+
+func (s *spanImpl) reset() {
+	s.tracer, s.numDroppedLogs = nil, 0
+	// Note that we can't reuse the Tags map or the Logs slice: ownership of
+	// both was handed to the Recorder when the span last finished.
+	s.raw = RawSpan{}
+}
+
+// trim reports whether tags and logs should be discarded. The caller must
+// hold s.Lock or have exclusive access to s.
+func (s *spanImpl) trim() bool {
+	return !s.raw.Context.Sampled && s.tracer.options.TrimUnsampledSpans
+}
+
+func (s *spanImpl) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	s.raw.Operation = operationName
+	return s
+}
+
+func (s *spanImpl) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	if key == string(ext.SamplingPriority) {
+		if priority, ok := samplingPriority(value); ok {
+			s.raw.Context.Sampled = priority > 0
+			return s
+		}
+	}
+	if s.trim() {
+		return s
+	}
+	if s.raw.Tags == nil {
+		s.raw.Tags = opentracing.Tags{}
+	}
+	s.raw.Tags[key] = value
+	return s
+}
+
+func (s *spanImpl) LogKV(keyValues ...interface{}) {
+	fields, err := log.InterleavedKVToFields(keyValues...)
+	if err != nil {
+		s.LogFields(log.Error(err), log.String("function", "LogKV"))
+		return
+	}
+	s.LogFields(fields...)
+}
+
+func (s *spanImpl) appendLog(lr opentracing.LogRecord) {
+	maxLogs := s.tracer.options.MaxLogsPerSpan
+	if maxLogs == 0 || len(s.raw.Logs) < maxLogs {
+		s.raw.Logs = append(s.raw.Logs, lr)
+		return
+	}
+	// Keep the oldest logs; the dropped-logs record added on Finish explains
+	// the gap.
+	s.numDroppedLogs++
+}
+
+func (s *spanImpl) LogFields(fields ...log.Field) {
+	lr := opentracing.LogRecord{
+		Fields: fields,
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.trim() || s.tracer.options.DropAllLogs {
+		return
+	}
+	lr.Timestamp = time.Now()
+	s.appendLog(lr)
+}
+
+func (s *spanImpl) LogEvent(event string) {
+	s.Log(opentracing.LogData{
+		Event: event,
+	})
+}
+
+func (s *spanImpl) LogEventWithPayload(event string, payload interface{}) {
+	s.Log(opentracing.LogData{
+		Event:   event,
+		Payload: payload,
+	})
+}
+
+func (s *spanImpl) Log(ld opentracing.LogData) {
+	s.Lock()
+	defer s.Unlock()
+	if s.trim() || s.tracer.options.DropAllLogs {
+		return
+	}
+	s.appendLog(ld.ToLogRecord())
+}
+
+func (s *spanImpl) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *spanImpl) FinishWithOptions(opts opentracing.FinishOptions) {
+	finishTime := opts.FinishTime
+	if finishTime.IsZero() {
+		finishTime = time.Now()
+	}
+
+	s.Lock()
+	duration := finishTime.Sub(s.raw.Start)
+	if !s.trim() && !s.tracer.options.DropAllLogs {
+		for _, lr := range opts.LogRecords {
+			s.appendLog(lr)
+		}
+		for _, ld := range opts.BulkLogData {
+			s.appendLog(ld.ToLogRecord())
+		}
+		if s.numDroppedLogs > 0 {
+			s.raw.Logs = append(s.raw.Logs, opentracing.LogRecord{
+				Timestamp: finishTime,
+				Fields: []log.Field{
+					log.String("event", "dropped Span logs"),
+					log.Int("dropped_log_count", s.numDroppedLogs),
+					log.String("component", "basictracer"),
+				},
+			})
+		}
+	}
+	s.raw.Duration = duration
+	raw := s.raw
+	tracer := s.tracer
+	s.Unlock()
+
+	if tracer.options.Recorder != nil {
+		tracer.options.Recorder.RecordSpan(raw)
+	}
+	tracer.putSpan(s)
+}
+
+func (s *spanImpl) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *spanImpl) Context() opentracing.SpanContext {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Context
+}
+
+func (s *spanImpl) SetBaggageItem(key, val string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	s.raw.Context = s.raw.Context.WithBaggageItem(key, val)
+	return s
+}
+
+func (s *spanImpl) BaggageItem(key string) string {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Context.Baggage[key]
+}
+
+func (s *spanImpl) Operation() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Operation
+}
+
+func (s *spanImpl) Start() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Start
+}
+
+// String allows printing span for debugging
+func (s *spanImpl) String() string {
+	s.Lock()
+	defer s.Unlock()
+	return fmt.Sprintf(
+		"traceId=%s, spanId=%x, parentId=%x, sampled=%t, name=%s",
+		formatTraceID(s.raw.Context), s.raw.Context.SpanID, s.raw.ParentSpanID,
+		s.raw.Context.Sampled, s.raw.Operation)
+}
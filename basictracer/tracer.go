@@ -0,0 +1,231 @@
+// Package basictracer is a reference implementation of opentracing.Tracer.
+//
+// Unlike mocktracer, basictracer is intended for production use: it
+// generates random 64- or 128-bit IDs, supports all BuiltinFormats for
+// propagation, and hands every finished span to a pluggable SpanRecorder,
+// which is responsible for buffering and exporting it.
+package basictracer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// Options allows creating a customized Tracer via NewWithOptions. The object
+// must not be updated when there is an active tracer using it.
+type Options struct {
+	// ShouldSample is a function which is called when creating a new Span and
+	// determines whether that Span is sampled. The randomized TraceID is
+	// supplied to allow deterministic sampling decisions to be made across
+	// different nodes. For example,
+	//
+	//   func(traceID uint64) { return traceID % 64 == 0 }
+	//
+	// samples every 64th trace on average.
+	ShouldSample func(traceID uint64) bool
+
+	// TrimUnsampledSpans turns potentially expensive operations on unsampled
+	// Spans into no-ops. More precisely, tags and log events are silently
+	// discarded.
+	TrimUnsampledSpans bool
+
+	// Recorder receives Spans which have been finished.
+	Recorder SpanRecorder
+
+	// DropAllLogs turns log events on all Spans into no-ops.
+	DropAllLogs bool
+
+	// MaxLogsPerSpan limits the number of Logs in a span (if set to a nonzero
+	// value). If a span has more logs than this value, logs are dropped as
+	// necessary, and a log record noting how many were dropped is added when
+	// the span finishes.
+	MaxLogsPerSpan int
+
+	// TraceID128Bit makes the tracer generate 128-bit trace IDs for root
+	// spans. Spans that continue an existing trace keep the parent's trace
+	// ID width.
+	TraceID128Bit bool
+
+	// EnableSpanPool enables the use of a pool, so that the tracer reuses
+	// span objects, rather than allocating a new one each time. This reduces
+	// allocations, but it is only safe if callers never touch a Span after
+	// calling Finish() on it.
+	EnableSpanPool bool
+}
+
+// DefaultOptions returns an Options object with a 100% sampling rate and no
+// Recorder. Since a recorder is required, it must be set before the tracer
+// is constructed.
+func DefaultOptions() Options {
+	return Options{
+		ShouldSample:   func(traceID uint64) bool { return true },
+		MaxLogsPerSpan: 100,
+	}
+}
+
+// Tracer is the basictracer opentracing.Tracer implementation.
+type Tracer struct {
+	options  Options
+	spanPool sync.Pool
+}
+
+// NewWithOptions creates a customized Tracer.
+func NewWithOptions(opts Options) *Tracer {
+	if opts.ShouldSample == nil {
+		opts.ShouldSample = DefaultOptions().ShouldSample
+	}
+	t := &Tracer{options: opts}
+	t.spanPool.New = func() interface{} {
+		return &spanImpl{}
+	}
+	return t
+}
+
+// New creates and returns a standard Tracer which defers completed Spans to
+// `recorder`.
+func New(recorder SpanRecorder) *Tracer {
+	opts := DefaultOptions()
+	opts.Recorder = recorder
+	return NewWithOptions(opts)
+}
+
+// Options gets the Options used in New() or NewWithOptions().
+func (t *Tracer) Options() Options {
+	return t.options
+}
+
+func (t *Tracer) getSpan() *spanImpl {
+	if t.options.EnableSpanPool {
+		sp := t.spanPool.Get().(*spanImpl)
+		sp.reset()
+		return sp
+	}
+	return &spanImpl{}
+}
+
+func (t *Tracer) putSpan(sp *spanImpl) {
+	if t.options.EnableSpanPool {
+		t.spanPool.Put(sp)
+	}
+}
+
+// StartSpan belongs to the opentracing.Tracer interface.
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	sso := opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&sso)
+	}
+	return t.startSpanWithOptions(operationName, sso)
+}
+
+func (t *Tracer) startSpanWithOptions(operationName string, opts opentracing.StartSpanOptions) opentracing.Span {
+	startTime := opts.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+
+	sp := t.getSpan()
+	sp.tracer = t
+	sp.raw.Operation = operationName
+	sp.raw.Start = startTime
+	sp.raw.Tags = opts.Tags
+
+	// The parent is the first reference to a SpanContext of this tracer
+	// that is not a link.
+	found := false
+	for _, ref := range opts.References {
+		if ref.Type == opentracing.LinkRef {
+			continue
+		}
+		refCtx, ok := ref.ReferencedContext.(SpanContext)
+		if !ok {
+			continue
+		}
+		sp.raw.Context.TraceIDHigh = refCtx.TraceIDHigh
+		sp.raw.Context.TraceID = refCtx.TraceID
+		sp.raw.Context.SpanID = randomID()
+		sp.raw.Context.Sampled = refCtx.Sampled
+		sp.raw.ParentSpanID = refCtx.SpanID
+		// Baggage maps are copy-on-write, so sharing the parent's is safe.
+		sp.raw.Context.Baggage = refCtx.Baggage
+		found = true
+		break
+	}
+	if !found {
+		if t.options.TraceID128Bit {
+			sp.raw.Context.TraceIDHigh, sp.raw.Context.TraceID = randomID2()
+			sp.raw.Context.SpanID = randomID()
+		} else {
+			sp.raw.Context.TraceID, sp.raw.Context.SpanID = randomID2()
+		}
+		sp.raw.Context.Sampled = t.options.ShouldSample(sp.raw.Context.TraceID)
+	}
+
+	if v, ok := sp.raw.Tags[string(ext.SamplingPriority)]; ok {
+		if priority, ok := samplingPriority(v); ok {
+			sp.raw.Context.Sampled = priority > 0
+			delete(sp.raw.Tags, string(ext.SamplingPriority))
+		}
+	}
+	if sp.trim() {
+		sp.raw.Tags = nil
+	}
+	return sp
+}
+
+// Inject belongs to the opentracing.Tracer interface.
+func (t *Tracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	spanContext, ok := sc.(SpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	switch format {
+	case opentracing.TextMap:
+		return textPropagator.Inject(spanContext, carrier)
+	case opentracing.HTTPHeaders:
+		return httpPropagator.Inject(spanContext, carrier)
+	case opentracing.Binary:
+		return binPropagator.Inject(spanContext, carrier)
+	}
+	return opentracing.ErrUnsupportedFormat
+}
+
+// Extract belongs to the opentracing.Tracer interface.
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	var (
+		spanContext SpanContext
+		err         error
+	)
+	switch format {
+	case opentracing.TextMap:
+		spanContext, err = textPropagator.Extract(carrier)
+	case opentracing.HTTPHeaders:
+		spanContext, err = httpPropagator.Extract(carrier)
+	case opentracing.Binary:
+		spanContext, err = binPropagator.Extract(carrier)
+	default:
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spanContext, nil
+}
+
+// samplingPriority coerces the value of a sampling.priority tag to an int.
+func samplingPriority(v interface{}) (int, bool) {
+	switch p := v.(type) {
+	case uint16:
+		return int(p), true
+	case int:
+		return p, true
+	case uint32:
+		return int(p), true
+	case int32:
+		return int(p), true
+	}
+	return 0, false
+}
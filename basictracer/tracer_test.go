@@ -0,0 +1,214 @@
+package basictracer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/harness"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+type probe struct{}
+
+func (probe) SameTrace(first, second opentracing.Span) bool {
+	a := first.Context().(SpanContext)
+	b := second.Context().(SpanContext)
+	return a.TraceIDHigh == b.TraceIDHigh && a.TraceID == b.TraceID
+}
+
+func (probe) SameSpanContext(span opentracing.Span, sc opentracing.SpanContext) bool {
+	other, ok := sc.(SpanContext)
+	if !ok {
+		return false
+	}
+	ctx := span.Context().(SpanContext)
+	return ctx.TraceIDHigh == other.TraceIDHigh &&
+		ctx.TraceID == other.TraceID &&
+		ctx.SpanID == other.SpanID
+}
+
+func TestAPICheck(t *testing.T) {
+	harness.RunAPIChecks(t, func() (tracer opentracing.Tracer, closer func()) {
+		return New(NewInMemoryRecorder()), nil
+	},
+		harness.CheckEverything(),
+		harness.UseProbe(probe{}),
+	)
+}
+
+func TestAPICheck128BitPooled(t *testing.T) {
+	harness.RunAPIChecks(t, func() (tracer opentracing.Tracer, closer func()) {
+		opts := DefaultOptions()
+		opts.Recorder = NewInMemoryRecorder()
+		opts.TraceID128Bit = true
+		opts.EnableSpanPool = true
+		return NewWithOptions(opts), nil
+	},
+		harness.CheckEverything(),
+		harness.UseProbe(probe{}),
+	)
+}
+
+func TestTracer_StartSpan(t *testing.T) {
+	recorder := NewInMemoryRecorder()
+	tracer := New(recorder)
+
+	parent := tracer.StartSpan("parent", opentracing.Tag{Key: "x", Value: "y"})
+	parent.SetBaggageItem("k", "v")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	assert.Equal(t, "v", child.BaggageItem("k"))
+	child.Finish()
+	parent.Finish()
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 2)
+	childRaw, parentRaw := spans[0], spans[1]
+	assert.Equal(t, "child", childRaw.Operation)
+	assert.Equal(t, parentRaw.Context.TraceID, childRaw.Context.TraceID)
+	assert.Equal(t, parentRaw.Context.SpanID, childRaw.ParentSpanID)
+	assert.NotEqual(t, parentRaw.Context.SpanID, childRaw.Context.SpanID)
+	assert.Zero(t, parentRaw.ParentSpanID)
+	assert.Zero(t, parentRaw.Context.TraceIDHigh)
+	assert.Equal(t, opentracing.Tags{"x": "y"}, parentRaw.Tags)
+	assert.True(t, parentRaw.Context.Sampled)
+}
+
+func TestTracer_LinkIsNotParent(t *testing.T) {
+	recorder := NewInMemoryRecorder()
+	tracer := New(recorder)
+
+	linked := tracer.StartSpan("linked")
+	parent := tracer.StartSpan("parent")
+	child := tracer.StartSpan("child",
+		opentracing.Link(linked.Context(), opentracing.Tags{"reason": "batch"}),
+		opentracing.ChildOf(parent.Context()))
+	root := tracer.StartSpan("root", opentracing.Link(linked.Context(), nil))
+
+	childCtx := child.Context().(SpanContext)
+	assert.Equal(t, parent.Context().(SpanContext).TraceID, childCtx.TraceID)
+	assert.NotEqual(t, linked.Context().(SpanContext).TraceID, root.Context().(SpanContext).TraceID)
+	child.Finish()
+	root.Finish()
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.Context().(SpanContext).SpanID, spans[0].ParentSpanID)
+	assert.Zero(t, spans[1].ParentSpanID)
+}
+
+func TestTracer_128BitTraceID(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Recorder = NewInMemoryRecorder()
+	opts.TraceID128Bit = true
+	tracer := NewWithOptions(opts)
+
+	parent := tracer.StartSpan("parent")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	parentCtx := parent.Context().(SpanContext)
+	childCtx := child.Context().(SpanContext)
+	assert.True(t, parentCtx.Is128Bit())
+	assert.Equal(t, parentCtx.TraceIDHigh, childCtx.TraceIDHigh)
+	assert.Equal(t, parentCtx.TraceID, childCtx.TraceID)
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	recorder := NewInMemoryRecorder()
+	opts := DefaultOptions()
+	opts.Recorder = recorder
+	opts.ShouldSample = func(traceID uint64) bool { return false }
+	opts.TrimUnsampledSpans = true
+	tracer := NewWithOptions(opts)
+
+	span := tracer.StartSpan("unsampled")
+	span.SetTag("x", "y")
+	span.LogFields(log.String("event", "dropped"))
+	span.Finish()
+
+	forced := tracer.StartSpan("forced")
+	ext.SamplingPriority.Set(forced, 1)
+	forced.SetTag("x", "y")
+	forced.Finish()
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 2)
+	assert.False(t, spans[0].Context.Sampled)
+	assert.Empty(t, spans[0].Tags)
+	assert.Empty(t, spans[0].Logs)
+	assert.True(t, spans[1].Context.Sampled)
+	assert.Equal(t, opentracing.Tags{"x": "y"}, spans[1].Tags)
+
+	sampled := recorder.GetSampledSpans()
+	require.Len(t, sampled, 1)
+	assert.Equal(t, "forced", sampled[0].Operation)
+}
+
+func TestTracer_MaxLogsPerSpan(t *testing.T) {
+	recorder := NewInMemoryRecorder()
+	opts := DefaultOptions()
+	opts.Recorder = recorder
+	opts.MaxLogsPerSpan = 2
+	tracer := NewWithOptions(opts)
+
+	span := tracer.StartSpan("x")
+	for i := 0; i < 5; i++ {
+		span.LogKV("i", i)
+	}
+	span.Finish()
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Logs, 3)
+	last := spans[0].Logs[2].Fields
+	assert.Equal(t, "dropped_log_count", last[1].Key())
+	assert.Equal(t, 3, last[1].Value())
+}
+
+func TestTracer_SpanPoolDoesNotShareState(t *testing.T) {
+	recorder := NewInMemoryRecorder()
+	opts := DefaultOptions()
+	opts.Recorder = recorder
+	opts.EnableSpanPool = true
+	tracer := NewWithOptions(opts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			span := tracer.StartSpan("x")
+			span.SetTag("i", i)
+			span.LogKV("i", i)
+			span.Finish()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[interface{}]bool{}
+	for _, raw := range recorder.GetSpans() {
+		require.Len(t, raw.Tags, 1)
+		require.Len(t, raw.Logs, 1)
+		assert.Equal(t, raw.Tags["i"], raw.Logs[0].Fields[0].Value())
+		seen[raw.Tags["i"]] = true
+	}
+	assert.Len(t, seen, 50)
+}
+
+func BenchmarkStartFinishSpan(b *testing.B) {
+	opts := DefaultOptions()
+	opts.Recorder = discardRecorder{}
+	opts.EnableSpanPool = true
+	tracer := NewWithOptions(opts)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tracer.StartSpan("op").Finish()
+	}
+}
+
+type discardRecorder struct{}
+
+func (discardRecorder) RecordSpan(RawSpan) {}
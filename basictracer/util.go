@@ -0,0 +1,49 @@
+package basictracer
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	seededIDGen  = rand.New(rand.NewSource(generateSeed()))
+	seededIDLock sync.Mutex
+)
+
+// generateSeed seeds the ID generator from crypto/rand so that separate
+// processes started at the same instant don't produce colliding IDs.
+func generateSeed() int64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// randomID returns a random, non-zero 64-bit ID.
+func randomID() uint64 {
+	seededIDLock.Lock()
+	defer seededIDLock.Unlock()
+	for {
+		if id := seededIDGen.Uint64(); id != 0 {
+			return id
+		}
+	}
+}
+
+// randomID2 returns two random, non-zero 64-bit IDs under a single lock.
+func randomID2() (uint64, uint64) {
+	seededIDLock.Lock()
+	defer seededIDLock.Unlock()
+	var first, second uint64
+	for first == 0 {
+		first = seededIDGen.Uint64()
+	}
+	for second == 0 {
+		second = seededIDGen.Uint64()
+	}
+	return first, second
+}
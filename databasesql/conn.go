@@ -0,0 +1,159 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+type wrappedConn struct {
+	parent driver.Conn
+	tracer *tracer
+}
+
+var (
+	_ driver.Conn               = (*wrappedConn)(nil)
+	_ driver.ConnPrepareContext = (*wrappedConn)(nil)
+	_ driver.ConnBeginTx        = (*wrappedConn)(nil)
+	_ driver.ExecerContext      = (*wrappedConn)(nil)
+	_ driver.QueryerContext     = (*wrappedConn)(nil)
+	_ driver.Pinger             = (*wrappedConn)(nil)
+	_ driver.SessionResetter    = (*wrappedConn)(nil)
+	_ driver.NamedValueChecker  = (*wrappedConn)(nil)
+)
+
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	sp := c.tracer.start(ctx, "sql:prepare", query)
+	defer func() { finish(sp, err) }()
+
+	if p, ok := c.parent.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.parent.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newWrappedStmt(stmt, c.parent, query, c.tracer), nil
+}
+
+func (c *wrappedConn) Close() error {
+	return c.parent.Close()
+}
+
+func (c *wrappedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	sp := c.tracer.start(ctx, "sql:begin", "")
+	defer func() { finish(sp, err) }()
+
+	if b, ok := c.parent.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+			return nil, errors.New("databasesql: driver does not support non-default transaction options")
+		}
+		tx, err = c.parent.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedTx{tx, ctx, c.tracer}, nil
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+	execerContext, hasExecerContext := c.parent.(driver.ExecerContext)
+	execer, hasExecer := c.parent.(driver.Execer)
+	if !hasExecerContext && !hasExecer {
+		// Let database/sql fall back to Prepare and Stmt.Exec.
+		return nil, driver.ErrSkip
+	}
+	// The span is only started once the parent has answered, since
+	// driver.ErrSkip makes database/sql retry with Prepare and Stmt.Exec,
+	// which are traced on their own.
+	startTime := time.Now()
+	if hasExecerContext {
+		res, err = execerContext.ExecContext(ctx, query, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err == nil {
+			res, err = execer.Exec(query, values)
+		}
+	}
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	sp := c.tracer.start(ctx, "sql:exec", query, opentracing.StartTime(startTime))
+	c.tracer.logArgs(sp, args)
+	finish(sp, err)
+	return res, err
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	queryerContext, hasQueryerContext := c.parent.(driver.QueryerContext)
+	queryer, hasQueryer := c.parent.(driver.Queryer)
+	if !hasQueryerContext && !hasQueryer {
+		// Let database/sql fall back to Prepare and Stmt.Query.
+		return nil, driver.ErrSkip
+	}
+	// See ExecContext.
+	startTime := time.Now()
+	if hasQueryerContext {
+		rows, err = queryerContext.QueryContext(ctx, query, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err == nil {
+			rows, err = queryer.Query(query, values)
+		}
+	}
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	sp := c.tracer.start(ctx, "sql:query", query, opentracing.StartTime(startTime))
+	c.tracer.logArgs(sp, args)
+	finish(sp, err)
+	if err != nil {
+		return nil, err
+	}
+	return newWrappedRows(ctx, rows, query, c.tracer), nil
+}
+
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator, which only exists since Go 1.15,
+// hence the anonymous interface.
+func (c *wrappedConn) IsValid() bool {
+	if v, ok := c.parent.(interface{ IsValid() bool }); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	// Let database/sql apply its default conversion.
+	return driver.ErrSkip
+}
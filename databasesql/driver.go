@@ -0,0 +1,257 @@
+// Package databasesql provides OpenTracing instrumentation for database/sql
+// by wrapping a database/sql/driver.Driver.
+//
+// Every Query, Exec, Prepare, Begin, Commit and Rollback produces a client
+// span that is a child of the span found in the call's context.Context, and
+// iterating over the returned rows produces one more span covering the
+// fetched batch. Register the wrapped driver under a new name and open it as
+// usual:
+//
+//     sql.Register("traced-postgres", databasesql.Wrap(&pq.Driver{}, tracer,
+//         databasesql.DBInstance("customers")))
+//     db, err := sql.Open("traced-postgres", dsn)
+//     ...
+//     rows, err := db.QueryContext(ctx, "SELECT ...")
+//
+// Only the context-aware database/sql methods (QueryContext, ExecContext,
+// ...) can carry a parent span, so calls without a span in their context are
+// not traced unless AllowRoot(true) is given.
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strconv"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+const (
+	defaultComponentName = "database/sql"
+	defaultDBType        = "sql"
+
+	// rowsTag records the number of rows fetched by a "sql:rows" span.
+	rowsTag = "db.rows"
+)
+
+type options struct {
+	componentName string
+	dbType        string
+	dbInstance    string
+	dbUser        string
+	statementFunc func(query string) string
+	maxStatement  int
+	recordArgs    bool
+	allowRoot     bool
+}
+
+// Option controls the behavior of the wrapped driver.
+type Option func(*options)
+
+// ComponentName returns an Option that sets the component tag of all spans.
+func ComponentName(componentName string) Option {
+	return func(o *options) {
+		o.componentName = componentName
+	}
+}
+
+// DBType returns an Option that sets the db.type tag, "sql" by default.
+func DBType(dbType string) Option {
+	return func(o *options) {
+		o.dbType = dbType
+	}
+}
+
+// DBInstance returns an Option that sets the db.instance tag.
+func DBInstance(instance string) Option {
+	return func(o *options) {
+		o.dbInstance = instance
+	}
+}
+
+// DBUser returns an Option that sets the db.user tag.
+func DBUser(user string) Option {
+	return func(o *options) {
+		o.dbUser = user
+	}
+}
+
+// StatementFunc returns an Option that transforms each statement before it
+// is recorded in the db.statement tag, e.g. to redact literals. If f returns
+// "", the tag is omitted.
+func StatementFunc(f func(query string) string) Option {
+	return func(o *options) {
+		o.statementFunc = f
+	}
+}
+
+// MaxStatementLength returns an Option that truncates the db.statement tag
+// to at most n bytes. Zero, the default, means no limit.
+func MaxStatementLength(n int) Option {
+	return func(o *options) {
+		o.maxStatement = n
+	}
+}
+
+// RecordArgs returns an Option that turns on or off logging of the
+// arguments of each Query and Exec. Arguments are off by default, since
+// they often contain sensitive data.
+func RecordArgs(enabled bool) Option {
+	return func(o *options) {
+		o.recordArgs = enabled
+	}
+}
+
+// AllowRoot returns an Option that turns on or off tracing of calls whose
+// context holds no span. When off, the default, such calls start no span.
+func AllowRoot(enabled bool) Option {
+	return func(o *options) {
+		o.allowRoot = enabled
+	}
+}
+
+// tracer holds the state shared by all wrappers of one driver.
+type tracer struct {
+	tracer opentracing.Tracer
+	opts   options
+}
+
+var noopSpan = opentracing.NoopTracer{}.StartSpan("")
+
+// start starts a span for operation, or returns a noop span if tracing is
+// disabled for ctx. `opts` are passed on to the tracer.
+func (t *tracer) start(ctx context.Context, operation, query string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil && !t.opts.allowRoot {
+		return noopSpan
+	}
+	var parentCtx opentracing.SpanContext
+	if parent != nil {
+		parentCtx = parent.Context()
+	}
+	opts = append([]opentracing.StartSpanOption{opentracing.ChildOf(parentCtx), ext.SpanKindRPCClient}, opts...)
+	sp := t.tracer.StartSpan(operation, opts...)
+	ext.Component.Set(sp, t.opts.componentName)
+	ext.DBType.Set(sp, t.opts.dbType)
+	if t.opts.dbInstance != "" {
+		ext.DBInstance.Set(sp, t.opts.dbInstance)
+	}
+	if t.opts.dbUser != "" {
+		ext.DBUser.Set(sp, t.opts.dbUser)
+	}
+	if query != "" {
+		if t.opts.statementFunc != nil {
+			query = t.opts.statementFunc(query)
+		}
+		if t.opts.maxStatement > 0 && len(query) > t.opts.maxStatement {
+			query = query[:t.opts.maxStatement]
+		}
+		if query != "" {
+			ext.DBStatement.Set(sp, query)
+		}
+	}
+	return sp
+}
+
+func (t *tracer) logArgs(sp opentracing.Span, args []driver.NamedValue) {
+	if !t.opts.recordArgs || len(args) == 0 {
+		return
+	}
+	fields := make([]log.Field, 0, len(args)+1)
+	fields = append(fields, log.Event("args"))
+	for _, arg := range args {
+		fields = append(fields, log.Object(argName(arg), arg.Value))
+	}
+	sp.LogFields(fields...)
+}
+
+func argName(arg driver.NamedValue) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return "$" + strconv.Itoa(arg.Ordinal)
+}
+
+// finish records err, if any, and finishes sp. driver.ErrSkip is not an
+// error from the application's point of view.
+func finish(sp opentracing.Span, err error) {
+	if err != nil && err != driver.ErrSkip {
+		ext.LogError(sp, err)
+	}
+	sp.Finish()
+}
+
+// Wrap returns a driver.Driver that traces all connections opened through d.
+func Wrap(d driver.Driver, tr opentracing.Tracer, opts ...Option) driver.Driver {
+	t := newTracer(tr, opts)
+	if dc, ok := d.(driver.DriverContext); ok {
+		return &wrappedDriverContext{wrappedDriver{d, t}, dc}
+	}
+	return &wrappedDriver{d, t}
+}
+
+// WrapConnector returns a driver.Connector that traces all connections it
+// opens, for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, tr opentracing.Tracer, opts ...Option) driver.Connector {
+	return &wrappedConnector{c, nil, newTracer(tr, opts)}
+}
+
+func newTracer(tr opentracing.Tracer, opts []Option) *tracer {
+	o := options{
+		componentName: defaultComponentName,
+		dbType:        defaultDBType,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &tracer{tracer: tr, opts: o}
+}
+
+type wrappedDriver struct {
+	parent driver.Driver
+	tracer *tracer
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{conn, d.tracer}, nil
+}
+
+type wrappedDriverContext struct {
+	wrappedDriver
+	parentCtx driver.DriverContext
+}
+
+func (d *wrappedDriverContext) OpenConnector(name string) (driver.Connector, error) {
+	c, err := d.parentCtx.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConnector{c, d, d.tracer}, nil
+}
+
+type wrappedConnector struct {
+	parent driver.Connector
+	driver driver.Driver // the wrapped Driver, if known
+	tracer *tracer
+}
+
+func (c *wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{conn, c.tracer}, nil
+}
+
+func (c *wrappedConnector) Driver() driver.Driver {
+	if c.driver != nil {
+		return c.driver
+	}
+	return &wrappedDriver{c.parent.Driver(), c.tracer}
+}
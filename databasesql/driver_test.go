@@ -0,0 +1,262 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func openDB(tr opentracing.Tracer, legacy bool, opts ...Option) *sql.DB {
+	return openConnector(tr, fakeConnector{legacy: legacy}, opts...)
+}
+
+func openConnector(tr opentracing.Tracer, c fakeConnector, opts ...Option) *sql.DB {
+	return sql.OpenDB(WrapConnector(c, tr, opts...))
+}
+
+func operations(spans []*mocktracer.MockSpan) []string {
+	ops := make([]string, len(spans))
+	for i, sp := range spans {
+		ops[i] = sp.OperationName
+	}
+	return ops
+}
+
+func TestQuery(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false, DBInstance("customers"), DBUser("alice"))
+	defer db.Close()
+	parent := tr.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	rows, err := db.QueryContext(ctx, "SELECT 3")
+	require.NoError(t, err)
+	var sum int64
+	for rows.Next() {
+		var i int64
+		require.NoError(t, rows.Scan(&i))
+		sum += i
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	assert.Equal(t, int64(6), sum)
+
+	spans := tr.FinishedSpans()
+	require.Equal(t, []string{"sql:query", "sql:rows"}, operations(spans))
+	parentID := parent.Context().(mocktracer.MockSpanContext).SpanID
+	for _, sp := range spans {
+		assert.Equal(t, parentID, sp.ParentID)
+	}
+	assert.Equal(t, map[string]interface{}{
+		string(ext.SpanKind):    ext.SpanKindRPCClientEnum,
+		string(ext.Component):   defaultComponentName,
+		string(ext.DBType):      "sql",
+		string(ext.DBInstance):  "customers",
+		string(ext.DBUser):      "alice",
+		string(ext.DBStatement): "SELECT 3",
+	}, spans[0].Tags())
+	assert.Equal(t, 3, spans[1].Tag(rowsTag))
+}
+
+func TestExec_StatementOptions(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false,
+		StatementFunc(func(q string) string { return strings.Replace(q, "secret", "?", -1) }),
+		MaxStatementLength(20),
+		RecordArgs(true),
+	)
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	_, err := db.ExecContext(ctx, "UPDATE users SET password = 'secret' WHERE id = $1", 42)
+	require.NoError(t, err)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sql:exec", spans[0].OperationName)
+	assert.Equal(t, "UPDATE users SET pas", spans[0].Tag(string(ext.DBStatement)))
+	logs := spans[0].Logs()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "args", logs[0].Fields[0].ValueString)
+	assert.Equal(t, "$1", logs[0].Fields[1].Key)
+	assert.Equal(t, "42", logs[0].Fields[1].ValueString)
+}
+
+func TestPrepare(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false)
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	stmt, err := db.PrepareContext(ctx, "INSERT x")
+	require.NoError(t, err)
+	_, err = stmt.ExecContext(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+
+	spans := tr.FinishedSpans()
+	assert.Equal(t, []string{"sql:prepare", "sql:exec"}, operations(spans))
+	for _, sp := range spans {
+		assert.Equal(t, "INSERT x", sp.Tag(string(ext.DBStatement)))
+	}
+}
+
+func TestLegacyDriverFallsBackToPrepare(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, true)
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	var i int64
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT 1").Scan(&i))
+	assert.Equal(t, int64(1), i)
+	_, err := db.ExecContext(ctx, "INSERT x")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]string{"sql:prepare", "sql:query", "sql:rows", "sql:prepare", "sql:exec"},
+		operations(tr.FinishedSpans()))
+	for _, sp := range tr.FinishedSpans() {
+		assert.Nil(t, sp.Tag(string(ext.Error)), sp.OperationName)
+	}
+}
+
+func TestLegacyExecerAndQueryer(t *testing.T) {
+	tr := mocktracer.New()
+	db := openConnector(tr, fakeConnector{execer: true})
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	var i int64
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT 1").Scan(&i))
+	assert.Equal(t, int64(1), i)
+	_, err := db.ExecContext(ctx, "INSERT x", 1)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT x", sql.Named("n", 1))
+	assert.Error(t, err)
+
+	assert.Equal(t,
+		[]string{"sql:query", "sql:rows", "sql:exec", "sql:exec"},
+		operations(tr.FinishedSpans()))
+}
+
+func TestSkippedExecAndQuery(t *testing.T) {
+	tr := mocktracer.New()
+	db := openConnector(tr, fakeConnector{skip: true})
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	var i int64
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT 1").Scan(&i))
+	_, err := db.ExecContext(ctx, "INSERT x")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]string{"sql:prepare", "sql:query", "sql:rows", "sql:prepare", "sql:exec"},
+		operations(tr.FinishedSpans()))
+}
+
+func TestIsValid(t *testing.T) {
+	for _, c := range []fakeConnector{{}, {invalid: true}} {
+		conn, err := WrapConnector(c, mocktracer.New()).Connect(context.Background())
+		require.NoError(t, err)
+		validator, ok := conn.(interface{ IsValid() bool })
+		require.True(t, ok)
+		assert.Equal(t, !c.invalid, validator.IsValid())
+	}
+}
+
+func TestDriverArgumentTypes(t *testing.T) {
+	for _, c := range []fakeConnector{{checker: true}, {converter: true}} {
+		tr := mocktracer.New()
+		db := openConnector(tr, c)
+		ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+		stmt, err := db.PrepareContext(ctx, "INSERT x")
+		require.NoError(t, err)
+		_, err = stmt.ExecContext(ctx, point{1, 2})
+		require.NoError(t, err, "%+v", c)
+		require.NoError(t, stmt.Close())
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false)
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "INSERT x")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, errFake, tx.Rollback())
+
+	_, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	assert.Equal(t, errFake, err)
+
+	spans := tr.FinishedSpans()
+	assert.Equal(t,
+		[]string{"sql:begin", "sql:exec", "sql:commit", "sql:begin", "sql:rollback", "sql:begin"},
+		operations(spans))
+	assert.Nil(t, spans[2].Tag(string(ext.Error)))
+	assert.Equal(t, true, spans[4].Tag(string(ext.Error)))
+	assert.Equal(t, true, spans[5].Tag(string(ext.Error)))
+}
+
+func TestErrors(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false)
+	defer db.Close()
+	ctx := opentracing.ContextWithSpan(context.Background(), tr.StartSpan("parent"))
+
+	_, err := db.QueryContext(ctx, "FAIL")
+	assert.Equal(t, errFake, err)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, true, spans[0].Tag(string(ext.Error)))
+	assert.Equal(t, "error", spans[0].Logs()[0].Fields[0].ValueString)
+}
+
+func TestRootSpans(t *testing.T) {
+	tr := mocktracer.New()
+	db := openDB(tr, false)
+	defer db.Close()
+	_, err := db.ExecContext(context.Background(), "INSERT x")
+	require.NoError(t, err)
+	assert.Empty(t, tr.FinishedSpans())
+
+	rootDB := openDB(tr, false, AllowRoot(true))
+	defer rootDB.Close()
+	_, err = rootDB.ExecContext(context.Background(), "INSERT x")
+	require.NoError(t, err)
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, 0, spans[0].ParentID)
+}
+
+func TestWrap(t *testing.T) {
+	tr := mocktracer.New()
+	sql.Register("databasesql-test", Wrap(fakeDriver{}, tr, AllowRoot(true)))
+	db, err := sql.Open("databasesql-test", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("INSERT x")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sql:exec"}, operations(tr.FinishedSpans()))
+}
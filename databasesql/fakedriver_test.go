@@ -0,0 +1,249 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The fake driver understands two kinds of statements:
+//
+//     SELECT <n>  returns n rows of a single column "i" holding 1..n
+//     FAIL        returns errFake
+//
+// Anything else is an exec that affects one row.
+var errFake = errors.New("fake failure")
+
+type fakeConnector struct {
+	// legacy makes connections implement only driver.Conn, so database/sql
+	// has to go through Prepare for every query.
+	legacy bool
+
+	// execer makes connections implement driver.Execer and driver.Queryer
+	// instead of their context variants.
+	execer bool
+
+	// checker and converter make legacy connections prepare statements that
+	// accept point arguments through driver.NamedValueChecker and
+	// driver.ColumnConverter, respectively.
+	checker, converter bool
+
+	// skip makes connections answer ExecContext and QueryContext with
+	// driver.ErrSkip, as some drivers do for queries with arguments.
+	skip bool
+
+	// invalid makes connections report that they must not be reused.
+	invalid bool
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	switch {
+	case c.skip:
+		return &skipConn{}, nil
+	case c.invalid:
+		return &invalidConn{}, nil
+	case c.execer:
+		return &execerConn{}, nil
+	case c.legacy || c.checker || c.converter:
+		return &legacyConn{checker: c.checker, converter: c.converter}, nil
+	}
+	return &fakeConn{}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{c}
+}
+
+type fakeDriver struct {
+	connector fakeConnector
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return d.connector.Connect(context.Background())
+}
+
+type legacyConn struct {
+	checker, converter bool
+
+	// skip makes connections answer ExecContext and QueryContext with
+	// driver.ErrSkip, as some drivers do for queries with arguments.
+	skip bool
+
+	// invalid makes connections report that they must not be reused.
+	invalid bool
+}
+
+func (c *legacyConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "FAIL" {
+		return nil, errFake
+	}
+	switch {
+	case c.checker:
+		return &checkingStmt{pointStmt{fakeStmt{query}}}, nil
+	case c.converter:
+		return &convertingStmt{pointStmt{fakeStmt{query}}}, nil
+	}
+	return &fakeStmt{query}, nil
+}
+
+func (c *legacyConn) Close() error { return nil }
+
+func (c *legacyConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeConn struct {
+	legacyConn
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		return nil, errFake
+	}
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return exec(query)
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return query2rows(query)
+}
+
+type execerConn struct {
+	legacyConn
+}
+
+func (c *execerConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return exec(query)
+}
+
+func (c *execerConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return query2rows(query)
+}
+
+type skipConn struct {
+	legacyConn
+}
+
+func (c *skipConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *skipConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+type invalidConn struct {
+	fakeConn
+}
+
+func (c *invalidConn) IsValid() bool { return false }
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return exec(s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return query2rows(s.query)
+}
+
+// point is an argument type that only the statements of checker and
+// converter connections accept, converting it to "x,y".
+type point struct {
+	x, y int
+}
+
+func convertPoint(v interface{}) (driver.Value, error) {
+	if p, ok := v.(point); ok {
+		return fmt.Sprintf("%d,%d", p.x, p.y), nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// pointStmt verifies that its single argument was converted from a point.
+type pointStmt struct {
+	fakeStmt
+}
+
+func (s *pointStmt) NumInput() int { return 1 }
+
+func (s *pointStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if len(args) != 1 || args[0] != "1,2" {
+		return nil, fmt.Errorf("unexpected args %v", args)
+	}
+	return exec(s.query)
+}
+
+type checkingStmt struct {
+	pointStmt
+}
+
+func (s *checkingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	var err error
+	nv.Value, err = convertPoint(nv.Value)
+	return err
+}
+
+type convertingStmt struct {
+	pointStmt
+}
+
+func (s *convertingStmt) ColumnConverter(int) driver.ValueConverter {
+	return driver.ValueConverter(valueConverterFunc(convertPoint))
+}
+
+type valueConverterFunc func(v interface{}) (driver.Value, error)
+
+func (f valueConverterFunc) ConvertValue(v interface{}) (driver.Value, error) {
+	return f(v)
+}
+
+func exec(query string) (driver.Result, error) {
+	if query == "FAIL" {
+		return nil, errFake
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func query2rows(query string) (driver.Rows, error) {
+	if query == "FAIL" {
+		return nil, errFake
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(query, "SELECT "))
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{n: n}, nil
+}
+
+type fakeRows struct {
+	i, n int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"i"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		return io.EOF
+	}
+	r.i++
+	dest[0] = int64(r.i)
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return errFake }
@@ -0,0 +1,119 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// wrappedRows traces the iteration over a result set as a single
+// "sql:rows" span, started by the first call to Next and finished at the
+// end of the rows or on Close, whichever comes first.
+type wrappedRows struct {
+	parent driver.Rows
+	ctx    context.Context
+	query  string
+	tracer *tracer
+
+	sp      opentracing.Span
+	numRows int
+}
+
+// newWrappedRows wraps rows, preserving the optional driver.Rows interfaces
+// that database/sql looks for.
+func newWrappedRows(ctx context.Context, rows driver.Rows, query string, t *tracer) driver.Rows {
+	r := &wrappedRows{parent: rows, ctx: ctx, query: query, tracer: t}
+	if _, ok := rows.(driver.RowsNextResultSet); ok {
+		return &wrappedRowsNextResultSet{r}
+	}
+	return r
+}
+
+func (r *wrappedRows) Columns() []string {
+	return r.parent.Columns()
+}
+
+func (r *wrappedRows) Next(dest []driver.Value) error {
+	if r.sp == nil {
+		r.sp = r.tracer.start(r.ctx, "sql:rows", r.query)
+	}
+	err := r.parent.Next(dest)
+	if err == nil {
+		r.numRows++
+		return nil
+	}
+	if err == io.EOF {
+		r.finish(nil)
+	} else {
+		r.finish(err)
+	}
+	return err
+}
+
+func (r *wrappedRows) Close() error {
+	err := r.parent.Close()
+	r.finish(err)
+	return err
+}
+
+func (r *wrappedRows) finish(err error) {
+	if r.sp == nil {
+		return
+	}
+	r.sp.SetTag(rowsTag, r.numRows)
+	finish(r.sp, err)
+	r.sp = nil
+	r.numRows = 0
+}
+
+func (r *wrappedRows) ColumnTypeScanType(index int) reflect.Type {
+	if c, ok := r.parent.(driver.RowsColumnTypeScanType); ok {
+		return c.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *wrappedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if c, ok := r.parent.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return c.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *wrappedRows) ColumnTypeLength(index int) (int64, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypeLength); ok {
+		return c.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *wrappedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypeNullable); ok {
+		return c.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *wrappedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypePrecisionScale); ok {
+		return c.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+// wrappedRowsNextResultSet starts a new "sql:rows" span for each result set.
+type wrappedRowsNextResultSet struct {
+	*wrappedRows
+}
+
+func (r *wrappedRowsNextResultSet) HasNextResultSet() bool {
+	return r.parent.(driver.RowsNextResultSet).HasNextResultSet()
+}
+
+func (r *wrappedRowsNextResultSet) NextResultSet() error {
+	r.finish(nil)
+	return r.parent.(driver.RowsNextResultSet).NextResultSet()
+}
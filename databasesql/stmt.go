@@ -0,0 +1,124 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+type wrappedStmt struct {
+	parent driver.Stmt
+	conn   driver.Conn
+	query  string
+	tracer *tracer
+}
+
+// wrappedStmtWithConverter is a wrappedStmt whose parent implements
+// driver.ColumnConverter. database/sql only consults a column converter if
+// the statement implements the interface, so it is a separate type.
+type wrappedStmtWithConverter struct {
+	*wrappedStmt
+}
+
+var (
+	_ driver.Stmt              = (*wrappedStmt)(nil)
+	_ driver.StmtExecContext   = (*wrappedStmt)(nil)
+	_ driver.StmtQueryContext  = (*wrappedStmt)(nil)
+	_ driver.NamedValueChecker = (*wrappedStmt)(nil)
+	_ driver.ColumnConverter   = wrappedStmtWithConverter{}
+)
+
+func newWrappedStmt(parent driver.Stmt, conn driver.Conn, query string, tr *tracer) driver.Stmt {
+	s := &wrappedStmt{parent, conn, query, tr}
+	if _, ok := parent.(driver.ColumnConverter); ok {
+		return wrappedStmtWithConverter{s}
+	}
+	return s
+}
+
+// CheckNamedValue forwards to the parent statement's checker or else, since
+// database/sql skips the connection's checker when the statement has one,
+// to the parent connection's.
+func (s *wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s wrappedStmtWithConverter) ColumnConverter(idx int) driver.ValueConverter {
+	return s.parent.(driver.ColumnConverter).ColumnConverter(idx)
+}
+
+func (s *wrappedStmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s *wrappedStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+func (s *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res driver.Result, err error) {
+	sp := s.tracer.start(ctx, "sql:exec", s.query)
+	defer func() { finish(sp, err) }()
+	s.tracer.logArgs(sp, args)
+
+	if e, ok := s.parent.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	values, err := plainValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.parent.Exec(values)
+}
+
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	sp := s.tracer.start(ctx, "sql:query", s.query)
+	defer func() { finish(sp, err) }()
+	s.tracer.logArgs(sp, args)
+
+	if q, ok := s.parent.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err != nil {
+			return nil, err
+		}
+		rows, err = s.parent.Query(values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newWrappedRows(ctx, rows, s.query, s.tracer), nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func plainValues(named []driver.NamedValue) ([]driver.Value, error) {
+	args := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("databasesql: driver does not support the use of Named Parameters")
+		}
+		args[i] = nv.Value
+	}
+	return args, nil
+}
@@ -0,0 +1,25 @@
+package databasesql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+type wrappedTx struct {
+	parent driver.Tx
+	// ctx is the context passed to BeginTx; Commit and Rollback have none.
+	ctx    context.Context
+	tracer *tracer
+}
+
+func (t *wrappedTx) Commit() (err error) {
+	sp := t.tracer.start(t.ctx, "sql:commit", "")
+	defer func() { finish(sp, err) }()
+	return t.parent.Commit()
+}
+
+func (t *wrappedTx) Rollback() (err error) {
+	sp := t.tracer.start(t.ctx, "sql:rollback", "")
+	defer func() { finish(sp, err) }()
+	return t.parent.Rollback()
+}
@@ -1,34 +1,85 @@
 package opentracing
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 type registeredTracer struct {
 	tracer       Tracer
 	isRegistered bool
 }
 
 var (
-	globalTracer = registeredTracer{NoopTracer{}, false}
+	// globalTracer always holds a registeredTracer. Readers load it without
+	// locking; writers serialize through globalTracerMu so that swaps observe
+	// a consistent previous value.
+	globalTracer   atomic.Value
+	globalTracerMu sync.Mutex
 )
 
+func init() {
+	globalTracer.Store(registeredTracer{NoopTracer{}, false})
+}
+
+func loadGlobalTracer() registeredTracer {
+	return globalTracer.Load().(registeredTracer)
+}
+
+func swapGlobalTracer(next registeredTracer) registeredTracer {
+	globalTracerMu.Lock()
+	defer globalTracerMu.Unlock()
+	prev := loadGlobalTracer()
+	globalTracer.Store(next)
+	return prev
+}
+
 // SetGlobalTracer sets the [singleton] opentracing.Tracer returned by
 // GlobalTracer(). Those who use GlobalTracer (rather than directly manage an
 // opentracing.Tracer instance) should call SetGlobalTracer as early as
 // possible in main(), prior to calling the `StartSpan` global func below.
 // Prior to calling `SetGlobalTracer`, any Spans started via the `StartSpan`
 // (etc) globals are noops.
+//
+// SetGlobalTracer is safe to call concurrently with GlobalTracer() and the
+// other globals.
 func SetGlobalTracer(tracer Tracer) {
-	globalTracer = registeredTracer{tracer, true}
+	swapGlobalTracer(registeredTracer{tracer, true})
+}
+
+// SwapGlobalTracer atomically replaces the global singleton `Tracer` with
+// `tracer` and returns the `Tracer` it replaced. Like SetGlobalTracer, it
+// marks the global tracer as registered.
+func SwapGlobalTracer(tracer Tracer) (previous Tracer) {
+	return swapGlobalTracer(registeredTracer{tracer, true}).tracer
+}
+
+// WithGlobalTracer installs `tracer` as the global singleton for the
+// duration of `fn`, then restores the previous global tracer, including
+// whether one was registered at all. It is intended for tests:
+//
+//     opentracing.WithGlobalTracer(mocktracer.New(), func() {
+//         ...
+//     })
+//
+// Note that the global tracer is process-wide, so concurrent callers of
+// WithGlobalTracer will observe each other's tracers.
+func WithGlobalTracer(tracer Tracer, fn func()) {
+	prev := swapGlobalTracer(registeredTracer{tracer, true})
+	defer swapGlobalTracer(prev)
+	fn()
 }
 
 // GlobalTracer returns the global singleton `Tracer` implementation.
 // Before `SetGlobalTracer()` is called, the `GlobalTracer()` is a noop
 // implementation that drops all data handed to it.
 func GlobalTracer() Tracer {
-	return globalTracer.tracer
+	return loadGlobalTracer().tracer
 }
 
 // StartSpan defers to `Tracer.StartSpan`. See `GlobalTracer()`.
 func StartSpan(operationName string, opts ...StartSpanOption) Span {
-	return globalTracer.tracer.StartSpan(operationName, opts...)
+	return GlobalTracer().StartSpan(operationName, opts...)
 }
 
 // InitGlobalTracer is deprecated. Please use SetGlobalTracer.
@@ -38,5 +89,5 @@ func InitGlobalTracer(tracer Tracer) {
 
 // IsGlobalTracerRegistered returns a `bool` to indicate if a tracer has been globally registered
 func IsGlobalTracerRegistered() bool {
-	return globalTracer.isRegistered
+	return loadGlobalTracer().isRegistered
 }
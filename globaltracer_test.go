@@ -2,6 +2,7 @@ package opentracing
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -24,3 +25,65 @@ func TestDefaultTracerIsNoopTracer(t *testing.T) {
 		t.Errorf("Should return false when no global tracer is registered.")
 	}
 }
+
+func TestSwapGlobalTracerReturnsPrevious(t *testing.T) {
+	defer swapGlobalTracer(loadGlobalTracer())
+
+	first := testTracer{}
+	SetGlobalTracer(first)
+	prev := SwapGlobalTracer(NoopTracer{})
+	if prev != first {
+		t.Errorf("Expected previous tracer %v, got %v", first, prev)
+	}
+	if GlobalTracer() != (NoopTracer{}) {
+		t.Errorf("Expected NoopTracer to be installed, got %v", GlobalTracer())
+	}
+	if !IsGlobalTracerRegistered() {
+		t.Errorf("Should return true after a tracer has been swapped in.")
+	}
+}
+
+func TestWithGlobalTracerRestoresPrevious(t *testing.T) {
+	prev := swapGlobalTracer(registeredTracer{NoopTracer{}, false})
+	defer swapGlobalTracer(prev)
+
+	called := false
+	WithGlobalTracer(testTracer{}, func() {
+		called = true
+		if GlobalTracer() != (testTracer{}) {
+			t.Errorf("Expected testTracer inside WithGlobalTracer, got %v", GlobalTracer())
+		}
+		if !IsGlobalTracerRegistered() {
+			t.Errorf("Should return true inside WithGlobalTracer.")
+		}
+	})
+	if !called {
+		t.Errorf("WithGlobalTracer did not call fn")
+	}
+	if GlobalTracer() != (NoopTracer{}) {
+		t.Errorf("Expected NoopTracer to be restored, got %v", GlobalTracer())
+	}
+	if IsGlobalTracerRegistered() {
+		t.Errorf("Should restore unregistered state after WithGlobalTracer.")
+	}
+}
+
+func TestGlobalTracerConcurrentAccess(t *testing.T) {
+	defer swapGlobalTracer(loadGlobalTracer())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetGlobalTracer(testTracer{})
+			SwapGlobalTracer(NoopTracer{})
+		}()
+		go func() {
+			defer wg.Done()
+			StartSpan("op").Finish()
+			IsGlobalTracerRegistered()
+		}()
+	}
+	wg.Wait()
+}
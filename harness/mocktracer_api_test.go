@@ -0,0 +1,33 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type mockProbe struct{}
+
+func (mockProbe) SameTrace(first, second opentracing.Span) bool {
+	return first.Context().(mocktracer.MockSpanContext).TraceID ==
+		second.Context().(mocktracer.MockSpanContext).TraceID
+}
+
+func (mockProbe) SameSpanContext(span opentracing.Span, sc opentracing.SpanContext) bool {
+	mockCtx, ok := sc.(mocktracer.MockSpanContext)
+	if !ok {
+		return false
+	}
+	spanCtx := span.Context().(mocktracer.MockSpanContext)
+	return spanCtx.TraceID == mockCtx.TraceID && spanCtx.SpanID == mockCtx.SpanID
+}
+
+func TestMockTracerAPI(t *testing.T) {
+	RunAPIChecks(t, func() (tracer opentracing.Tracer, closer func()) {
+		return mocktracer.New(), nil
+	},
+		CheckEverything(),
+		UseProbe(mockProbe{}),
+	)
+}
@@ -0,0 +1,97 @@
+// Package messaging provides helpers that tie the producing and consuming of
+// a message on a message bus into one trace.
+//
+// The producer injects its span context into the message headers:
+//
+//     sp, ctx := messaging.StartProducerSpan(ctx, "orders", opentracing.TextMapCarrier(msg.Headers))
+//     defer sp.Finish()
+//     err := producer.Send(ctx, msg)
+//
+// and the consumer continues the trace from them:
+//
+//     sp := messaging.StartConsumerSpan(opentracing.TextMapCarrier(msg.Headers), "orders")
+//     defer sp.Finish()
+//
+// The consumer span refers to the producer span with FollowsFrom, since the
+// producer does not wait for the message to be processed.
+package messaging
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// StartProducerSpan starts a producer span for sending a message to `dest`,
+// using any Span found within `ctx` as a ChildOfRef, and injects it into
+// `headers` in the TextMap format. It uses the global tracer.
+//
+// The second return value is a context.Context object built around the
+// returned Span.
+func StartProducerSpan(ctx context.Context, dest string, headers opentracing.TextMapWriter, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	return StartProducerSpanWithTracer(ctx, opentracing.GlobalTracer(), dest, headers, opts...)
+}
+
+// StartProducerSpanWithTracer is like StartProducerSpan but takes an
+// explicit tracer as opposed to using the global tracer.
+func StartProducerSpanWithTracer(ctx context.Context, tracer opentracing.Tracer, dest string, headers opentracing.TextMapWriter, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	// Copy opts, so that callers may share it between goroutines.
+	opts = append(append(make([]opentracing.StartSpanOption, 0, len(opts)+2), opts...),
+		ext.SpanKindProducer, opentracing.Tag{Key: string(ext.MessageBusDestination), Value: dest})
+	sp, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "send "+dest, opts...)
+	if err := tracer.Inject(sp.Context(), opentracing.TextMap, headers); err != nil {
+		sp.LogFields(log.String("event", "inject failed"), log.Error(err))
+	}
+	return sp, ctx
+}
+
+// StartConsumerSpan starts a consumer span for a message received from
+// `dest`. The span FollowsFrom the producer span extracted from `headers`
+// in the TextMap format, or is a root span if `headers` carry none. It uses
+// the global tracer.
+func StartConsumerSpan(headers opentracing.TextMapReader, dest string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	return StartConsumerSpanWithTracer(opentracing.GlobalTracer(), headers, dest, opts...)
+}
+
+// StartConsumerSpanWithTracer is like StartConsumerSpan but takes an
+// explicit tracer as opposed to using the global tracer.
+func StartConsumerSpanWithTracer(tracer opentracing.Tracer, headers opentracing.TextMapReader, dest string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	return StartBatchConsumerSpanWithTracer(tracer, dest, []opentracing.TextMapReader{headers}, opts...)
+}
+
+// StartBatchConsumerSpan starts a single consumer span for a batch of
+// messages received from `dest`. The span FollowsFrom every producer span
+// extracted from `batch`, one TextMapReader per message. It uses the global
+// tracer.
+func StartBatchConsumerSpan(dest string, batch []opentracing.TextMapReader, opts ...opentracing.StartSpanOption) opentracing.Span {
+	return StartBatchConsumerSpanWithTracer(opentracing.GlobalTracer(), dest, batch, opts...)
+}
+
+// StartBatchConsumerSpanWithTracer is like StartBatchConsumerSpan but takes
+// an explicit tracer as opposed to using the global tracer.
+//
+// Messages whose headers carry no span context are skipped. If extraction
+// fails for any other reason, the error is logged on the returned span.
+func StartBatchConsumerSpanWithTracer(tracer opentracing.Tracer, dest string, batch []opentracing.TextMapReader, opts ...opentracing.StartSpanOption) opentracing.Span {
+	// Copy opts, so that callers may share it between goroutines.
+	opts = append(make([]opentracing.StartSpanOption, 0, len(opts)+len(batch)+2), opts...)
+	var errs []error
+	for _, headers := range batch {
+		sc, err := tracer.Extract(opentracing.TextMap, headers)
+		switch err {
+		case nil:
+			opts = append(opts, opentracing.FollowsFrom(sc))
+		case opentracing.ErrSpanContextNotFound:
+		default:
+			errs = append(errs, err)
+		}
+	}
+	opts = append(opts, ext.SpanKindConsumer, opentracing.Tag{Key: string(ext.MessageBusDestination), Value: dest})
+	sp := tracer.StartSpan("receive "+dest, opts...)
+	for _, err := range errs {
+		sp.LogFields(log.String("event", "extract failed"), log.Error(err))
+	}
+	return sp
+}
@@ -0,0 +1,140 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestProducerConsumer(t *testing.T) {
+	tr := mocktracer.New()
+	parent := tr.StartSpan("handler")
+	headers := opentracing.TextMapCarrier{}
+
+	producer, ctx := StartProducerSpanWithTracer(opentracing.ContextWithSpan(context.Background(), parent), tr, "orders", headers)
+	producer.Finish()
+	assert.Equal(t, producer, opentracing.SpanFromContext(ctx))
+	assert.NotEmpty(t, headers)
+
+	consumer := StartConsumerSpanWithTracer(tr, headers, "orders")
+	consumer.Finish()
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 2)
+	p, c := spans[0], spans[1]
+	parentCtx := parent.Context().(mocktracer.MockSpanContext)
+
+	assert.Equal(t, "send orders", p.OperationName)
+	assert.Equal(t, parentCtx.SpanID, p.ParentID)
+	assert.Equal(t, ext.SpanKindProducerEnum, p.Tag(string(ext.SpanKind)))
+	assert.Equal(t, "orders", p.Tag(string(ext.MessageBusDestination)))
+
+	assert.Equal(t, "receive orders", c.OperationName)
+	assert.Equal(t, p.SpanContext.SpanID, c.ParentID)
+	assert.Equal(t, parentCtx.TraceID, c.SpanContext.TraceID)
+	assert.Equal(t, ext.SpanKindConsumerEnum, c.Tag(string(ext.SpanKind)))
+	assert.Equal(t, "orders", c.Tag(string(ext.MessageBusDestination)))
+}
+
+func TestConsumerWithoutProducer(t *testing.T) {
+	tr := mocktracer.New()
+	sp := StartConsumerSpanWithTracer(tr, opentracing.TextMapCarrier{}, "orders")
+	sp.Finish()
+	ms := sp.(*mocktracer.MockSpan)
+	assert.Equal(t, 0, ms.ParentID)
+	assert.Empty(t, ms.Logs())
+}
+
+func TestConsumerExtractError(t *testing.T) {
+	tr := &recordingTracer{MockTracer: mocktracer.New()}
+	sp := StartConsumerSpanWithTracer(tr, corruptHeaders{}, "orders")
+	sp.Finish()
+	ms := sp.(*mocktracer.MockSpan)
+	assert.Equal(t, 0, ms.ParentID)
+	assert.Len(t, ms.Logs(), 1)
+}
+
+func TestBatchConsumer(t *testing.T) {
+	tr := &recordingTracer{MockTracer: mocktracer.New()}
+	var batch []opentracing.TextMapReader
+	var producers []opentracing.Span
+	for i := 0; i < 3; i++ {
+		headers := opentracing.TextMapCarrier{}
+		sp, _ := StartProducerSpanWithTracer(context.Background(), tr, "orders", headers)
+		sp.Finish()
+		producers = append(producers, sp)
+		batch = append(batch, headers)
+	}
+	batch = append(batch, opentracing.TextMapCarrier{})
+
+	sp := StartBatchConsumerSpanWithTracer(tr, "orders", batch)
+	sp.Finish()
+
+	refs := tr.lastOptions.References
+	require.Len(t, refs, 3)
+	for i, ref := range refs {
+		assert.Equal(t, opentracing.FollowsFromRef, ref.Type)
+		assert.Equal(t, producers[i].Context(), ref.ReferencedContext)
+	}
+	assert.Equal(t, producers[0].Context().(mocktracer.MockSpanContext).SpanID, sp.(*mocktracer.MockSpan).ParentID)
+}
+
+func TestGlobalTracer(t *testing.T) {
+	tr := mocktracer.New()
+	opentracing.WithGlobalTracer(tr, func() {
+		headers := opentracing.TextMapCarrier{}
+		p, _ := StartProducerSpan(context.Background(), "orders", headers)
+		p.Finish()
+		StartConsumerSpan(headers, "orders").Finish()
+		StartBatchConsumerSpan("orders", []opentracing.TextMapReader{headers}).Finish()
+	})
+	assert.Len(t, tr.FinishedSpans(), 3)
+}
+
+func TestCallerOptionsAreNotModified(t *testing.T) {
+	tr := mocktracer.New()
+	headers := opentracing.TextMapCarrier{}
+	opts := make([]opentracing.StartSpanOption, 1, 8)
+	opts[0] = opentracing.Tag{Key: "k", Value: "v"}
+
+	p, _ := StartProducerSpanWithTracer(context.Background(), tr, "orders", headers, opts...)
+	p.Finish()
+	StartBatchConsumerSpanWithTracer(tr, "orders", []opentracing.TextMapReader{headers}, opts...).Finish()
+
+	assert.Equal(t, make([]opentracing.StartSpanOption, 7), opts[1:cap(opts)])
+	for _, sp := range tr.FinishedSpans() {
+		assert.Equal(t, "v", sp.Tag("k"))
+	}
+}
+
+// corruptHeaders is a carrier that recordingTracer fails to extract from.
+type corruptHeaders struct {
+	opentracing.TextMapCarrier
+}
+
+// recordingTracer records the options of the last started span.
+type recordingTracer struct {
+	*mocktracer.MockTracer
+	lastOptions opentracing.StartSpanOptions
+}
+
+func (t *recordingTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	t.lastOptions = opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&t.lastOptions)
+	}
+	return t.MockTracer.StartSpan(operationName, opts...)
+}
+
+func (t *recordingTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if _, ok := carrier.(corruptHeaders); ok {
+		return nil, opentracing.ErrSpanContextCorrupted
+	}
+	return t.MockTracer.Extract(format, carrier)
+}
@@ -0,0 +1,66 @@
+package mocktracer
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/propagation"
+)
+
+// PropagatorAdapter adapts a tracer-independent propagation.Propagator
+// (such as a propagation.CompositePropagator) so that it can be passed to
+// MockTracer.RegisterInjector and MockTracer.RegisterExtractor.
+type PropagatorAdapter struct {
+	Propagator propagation.Propagator
+}
+
+// FromPropagator returns a PropagatorAdapter for p.
+func FromPropagator(p propagation.Propagator) *PropagatorAdapter {
+	return &PropagatorAdapter{Propagator: p}
+}
+
+// Inject implements the Injector interface
+func (a *PropagatorAdapter) Inject(spanContext MockSpanContext, carrier interface{}) error {
+	return a.Propagator.Inject(spanContext, carrier)
+}
+
+// Extract implements the Extractor interface. It returns
+// opentracing.ErrInvalidSpanContext if the Propagator produces anything but
+// a MockSpanContext.
+func (a *PropagatorAdapter) Extract(carrier interface{}) (MockSpanContext, error) {
+	sc, err := a.Propagator.Extract(carrier)
+	if err != nil {
+		return emptyContext, err
+	}
+	mockContext, ok := sc.(MockSpanContext)
+	if !ok {
+		return emptyContext, opentracing.ErrInvalidSpanContext
+	}
+	return mockContext, nil
+}
+
+type mockPropagator struct {
+	injector  Injector
+	extractor Extractor
+}
+
+// ToPropagator adapts a mocktracer Injector and Extractor, such as
+// TextMapPropagator, to the propagation.Propagator interface, e.g. to use
+// them as part of a propagation.CompositePropagator.
+func ToPropagator(injector Injector, extractor Extractor) propagation.Propagator {
+	return mockPropagator{injector, extractor}
+}
+
+func (p mockPropagator) Inject(sc opentracing.SpanContext, carrier interface{}) error {
+	mockContext, ok := sc.(MockSpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	return p.injector.Inject(mockContext, carrier)
+}
+
+func (p mockPropagator) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	mockContext, err := p.extractor.Extract(carrier)
+	if err != nil {
+		return nil, err
+	}
+	return mockContext, nil
+}
@@ -0,0 +1,115 @@
+package mocktracer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/harness"
+	"github.com/opentracing/opentracing-go/propagation"
+	"github.com/opentracing/opentracing-go/propagation/b3"
+)
+
+// b3Propagator maps MockSpanContext to B3 headers.
+type b3Propagator struct{}
+
+func (b3Propagator) Inject(sc opentracing.SpanContext, carrier interface{}) error {
+	mockContext, ok := sc.(MockSpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	sampling := b3.SamplingDeny
+	if mockContext.Sampled {
+		sampling = b3.SamplingAccept
+	}
+	return b3.InjectMulti(b3.Context{
+		TraceID:  uint64(mockContext.TraceID),
+		SpanID:   uint64(mockContext.SpanID),
+		Sampling: sampling,
+	}, writer)
+}
+
+func (b3Propagator) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+	c, err := b3.Extract(reader)
+	if err != nil {
+		return nil, err
+	}
+	return MockSpanContext{
+		TraceID: int(c.TraceID),
+		SpanID:  int(c.SpanID),
+		Sampled: c.Sampling != b3.SamplingDeny,
+	}, nil
+}
+
+func TestCompositePropagator_MockTracer(t *testing.T) {
+	var matched []string
+	composite := propagation.NewCompositePropagator(
+		propagation.NamedPropagator{Name: "b3", Propagator: b3Propagator{}},
+		propagation.NamedPropagator{
+			Name:       "mock",
+			Propagator: ToPropagator(&TextMapPropagator{HTTPHeaders: true}, &TextMapPropagator{HTTPHeaders: true}),
+		},
+	)
+	composite.OnExtract = func(name string) { matched = append(matched, name) }
+
+	tracer := New()
+	tracer.RegisterInjector(opentracing.HTTPHeaders, FromPropagator(composite))
+	tracer.RegisterExtractor(opentracing.HTTPHeaders, FromPropagator(composite))
+
+	span := tracer.StartSpan("x")
+	spanContext := span.Context().(MockSpanContext)
+	h := http.Header{}
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(h)))
+	assert.NotEmpty(t, h.Get("X-B3-TraceId"))
+	assert.NotEmpty(t, h.Get("Mockpfx-Ids-Traceid"))
+
+	// A peer that only speaks the legacy mock format.
+	h.Del("X-B3-TraceId")
+	h.Del("X-B3-SpanId")
+	h.Del("X-B3-Sampled")
+	extracted, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, spanContext.TraceID, extracted.(MockSpanContext).TraceID)
+	assert.Equal(t, spanContext.SpanID, extracted.(MockSpanContext).SpanID)
+	assert.Equal(t, []string{"mock"}, matched)
+
+	assert.Equal(t, opentracing.ErrInvalidSpanContext,
+		tracer.Inject(harness.ForeignSpanContext{}, opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(h)))
+}
+
+func TestPropagatorAdapter_ForeignContext(t *testing.T) {
+	foreign := extractOnly(func(interface{}) (opentracing.SpanContext, error) {
+		return harness.ForeignSpanContext{}, nil
+	})
+
+	_, err := FromPropagator(foreign).Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrInvalidSpanContext, err)
+
+	mockAsGeneric := ToPropagator(new(TextMapPropagator), new(TextMapPropagator))
+	assert.Equal(t, opentracing.ErrInvalidSpanContext,
+		mockAsGeneric.Inject(harness.ForeignSpanContext{}, opentracing.TextMapCarrier{}))
+	sc, err := mockAsGeneric.Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	assert.Nil(t, sc)
+}
+
+type extractOnly func(carrier interface{}) (opentracing.SpanContext, error)
+
+func (f extractOnly) Inject(opentracing.SpanContext, interface{}) error {
+	return opentracing.ErrUnsupportedFormat
+}
+
+func (f extractOnly) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	return f(carrier)
+}
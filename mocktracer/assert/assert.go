@@ -0,0 +1,173 @@
+// Package assert provides fluent assertions on the spans recorded by a
+// mocktracer.MockTracer.
+//
+// Individual spans are found with Matchers:
+//
+//     a := assert.New(t, tracer)
+//     server := a.HasSpan(assert.OperationName("GET /x"), assert.Tag("http.status_code", 200))
+//     a.HasSpan(assert.OperationName("SELECT"), assert.ChildOf(server), assert.LoggedEvent("error"))
+//
+// and the shape of a whole trace with HasTrace:
+//
+//     a.HasTrace(assert.Span(assert.OperationName("GET /x")).With(
+//         assert.Span(assert.OperationName("SELECT")),
+//         assert.Span(assert.OperationName("render")),
+//     ))
+//
+// On failure, the recorded spans are printed as trees, so that the mismatch
+// can be found without writing loops over MockTracer.FinishedSpans().
+package assert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// TestingT is the subset of testing.T used to report failures.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// Assertions checks the finished spans of a MockTracer.
+type Assertions struct {
+	t      TestingT
+	tracer *mocktracer.MockTracer
+}
+
+// New returns Assertions reporting failures of the spans recorded by
+// `tracer` to `t`.
+func New(t TestingT, tracer *mocktracer.MockTracer) *Assertions {
+	return &Assertions{t: t, tracer: tracer}
+}
+
+// HasSpan asserts that a finished span matches all of the matchers, and
+// returns the first such span, or nil.
+func (a *Assertions) HasSpan(matchers ...Matcher) *mocktracer.MockSpan {
+	if h, ok := a.t.(tHelper); ok {
+		h.Helper()
+	}
+	spans := a.tracer.FinishedSpans()
+	for _, sp := range spans {
+		if len(matchAll(sp, matchers)) == 0 {
+			return sp
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "no finished span matches: %s\n", describe(matchers))
+	writeMismatches(&b, newForest(a.tracer.Traces()), matchers)
+	a.t.Errorf("%s", b.String())
+	return nil
+}
+
+// NoSpan asserts that no finished span matches all of the matchers.
+func (a *Assertions) NoSpan(matchers ...Matcher) bool {
+	if h, ok := a.t.(tHelper); ok {
+		h.Helper()
+	}
+	return a.SpanCount(0, matchers...)
+}
+
+// SpanCount asserts that exactly `n` finished spans match all of the
+// matchers.
+func (a *Assertions) SpanCount(n int, matchers ...Matcher) bool {
+	if h, ok := a.t.(tHelper); ok {
+		h.Helper()
+	}
+	spans := a.tracer.FinishedSpans()
+	count := 0
+	for _, sp := range spans {
+		if len(matchAll(sp, matchers)) == 0 {
+			count++
+		}
+	}
+	if count == n {
+		return true
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "expected %d finished spans matching %s, found %d\n", n, describe(matchers), count)
+	writeTrees(&b, newForest(a.tracer.Traces()))
+	a.t.Errorf("%s", b.String())
+	return false
+}
+
+// HasTrace asserts that a recorded trace has exactly the shape described
+// by `root`: its root span and every descendant match the corresponding
+// SpanNode, and no span has children beyond those expected. The order of
+// children does not matter.
+func (a *Assertions) HasTrace(root *SpanNode) bool {
+	if h, ok := a.t.(tHelper); ok {
+		h.Helper()
+	}
+	f := newForest(a.tracer.Traces())
+	var closest *mocktracer.MockSpan
+	closestDiff := -1
+	for _, r := range f.roots {
+		n := f.diff(nil, root, r, 0)
+		if n == 0 {
+			return true
+		}
+		if closestDiff < 0 || n < closestDiff {
+			closest, closestDiff = r, n
+		}
+	}
+	var b strings.Builder
+	b.WriteString("no recorded trace matches the expected tree\n")
+	if closest == nil {
+		b.WriteString("no finished spans\n")
+	} else {
+		b.WriteString("diff against the closest trace (- expected, + recorded):\n")
+		f.diff(&b, root, closest, 0)
+		writeTrees(&b, f)
+	}
+	a.t.Errorf("%s", b.String())
+	return false
+}
+
+// SpanNode describes a span and its children in an expected trace tree.
+type SpanNode struct {
+	matchers []Matcher
+	children []*SpanNode
+}
+
+// Span returns a SpanNode for a span that matches all of the matchers.
+func Span(matchers ...Matcher) *SpanNode {
+	return &SpanNode{matchers: matchers}
+}
+
+// With adds the expected children of the span and returns the SpanNode.
+func (n *SpanNode) With(children ...*SpanNode) *SpanNode {
+	n.children = append(n.children, children...)
+	return n
+}
+
+func writeMismatches(b *strings.Builder, f *forest, matchers []Matcher) {
+	if len(f.roots) == 0 {
+		b.WriteString("no finished spans\n")
+		return
+	}
+	b.WriteString("finished spans:\n")
+	var walk func(sp *mocktracer.MockSpan, depth int)
+	walk = func(sp *mocktracer.MockSpan, depth int) {
+		fmt.Fprintf(b, "  %s%s", strings.Repeat("  ", depth), formatSpan(sp))
+		if errs := matchAll(sp, matchers); len(errs) > 0 {
+			fmt.Fprintf(b, "  (%s)", strings.Join(errs, "; "))
+		}
+		b.WriteString("\n")
+		for _, c := range f.children[sp] {
+			walk(c, depth+1)
+		}
+	}
+	for _, r := range f.roots {
+		walk(r, 0)
+	}
+}
+
+func writeTrees(b *strings.Builder, f *forest) {
+	writeMismatches(b, f, nil)
+}
@@ -0,0 +1,124 @@
+package assert
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type recordingT struct {
+	failures []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+// recordRequest records a server span with two children, started one
+// millisecond apart so that their order is deterministic.
+func recordRequest(tracer *mocktracer.MockTracer, status uint16) (server, query opentracing.Span) {
+	start := time.Now()
+	server = tracer.StartSpan("GET /x", opentracing.StartTime(start), ext.SpanKindRPCServer)
+	ext.HTTPStatusCode.Set(server, status)
+	query = tracer.StartSpan("SELECT", opentracing.ChildOf(server.Context()), opentracing.StartTime(start.Add(time.Millisecond)))
+	query.LogFields(log.String("event", "error"))
+	query.Finish()
+	render := tracer.StartSpan("render", opentracing.FollowsFrom(server.Context()), opentracing.StartTime(start.Add(2*time.Millisecond)))
+	render.Finish()
+	server.Finish()
+	return server, query
+}
+
+func TestHasSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	server, query := recordRequest(tracer, 200)
+
+	rt := &recordingT{}
+	a := New(rt, tracer)
+	got := a.HasSpan(OperationName("GET /x"), Root(), Tag("http.status_code", 200), HasTag("span.kind"))
+	require.Equal(t, server, got)
+	got = a.HasSpan(OperationName("SELECT"), ChildOf(got), LoggedEvent("error"))
+	require.Equal(t, query, got)
+	a.HasSpan(OperationName("render"), FollowsFrom(server))
+	a.HasSpan(MatcherFunc("started", func(sp *mocktracer.MockSpan) bool { return !sp.StartTime.IsZero() }))
+	a.NoSpan(OperationName("POST /x"))
+	a.SpanCount(3)
+	a.SpanCount(2, MatcherFunc("non-root", func(sp *mocktracer.MockSpan) bool { return sp.ParentID != 0 }))
+	require.Empty(t, rt.failures)
+}
+
+func TestHasSpanFailure(t *testing.T) {
+	tracer := mocktracer.New()
+	recordRequest(tracer, 500)
+
+	rt := &recordingT{}
+	a := New(rt, tracer)
+	require.Nil(t, a.HasSpan(OperationName("GET /x"), Tag("http.status_code", 200)))
+	require.Len(t, rt.failures, 1)
+	require.Equal(t, `no finished span matches: operation name "GET /x", tag http.status_code=200
+finished spans:
+  GET /x [http.status_code=500 span.kind=server]  (tag http.status_code=500)
+    SELECT {error}  (operation name is "SELECT"; tag http.status_code is not set)
+    render  (operation name is "render"; tag http.status_code is not set)
+`, rt.failures[0])
+
+	a.HasSpan(ChildOf(nil))
+	a.NoSpan(OperationName("SELECT"))
+	require.Len(t, rt.failures, 3)
+}
+
+func TestHasTrace(t *testing.T) {
+	tracer := mocktracer.New()
+	recordRequest(tracer, 200)
+
+	rt := &recordingT{}
+	a := New(rt, tracer)
+	require.True(t, a.HasTrace(Span(OperationName("GET /x")).With(
+		Span(OperationName("render")),
+		Span(OperationName("SELECT"), LoggedEvent("error")),
+	)))
+	require.Empty(t, rt.failures)
+}
+
+func TestHasTraceFailure(t *testing.T) {
+	tracer := mocktracer.New()
+	recordRequest(tracer, 200)
+	tracer.StartSpan("unrelated").Finish()
+
+	rt := &recordingT{}
+	a := New(rt, tracer)
+	require.False(t, a.HasTrace(Span(OperationName("GET /x")).With(
+		Span(OperationName("SELECT"), Tag("db.type", "sql")),
+		Span(OperationName("cache")).With(Span(OperationName("redis"))),
+	)))
+	require.Len(t, rt.failures, 1)
+	require.Equal(t, `no recorded trace matches the expected tree
+diff against the closest trace (- expected, + recorded):
+  GET /x [http.status_code=200 span.kind=server]
+-   operation name "SELECT", tag db.type=sql
++   SELECT {error}  (tag db.type is not set)
+-   operation name "cache"
+-     operation name "redis"
++   render
+finished spans:
+  GET /x [http.status_code=200 span.kind=server]
+    SELECT {error}
+    render
+  unrelated
+`, rt.failures[0])
+}
+
+func TestTagValues(t *testing.T) {
+	require.True(t, valuesEqual(uint16(200), 200))
+	require.True(t, valuesEqual(int64(-1), float64(-1)))
+	require.True(t, valuesEqual("a", "a"))
+	require.False(t, valuesEqual("200", 200))
+	require.False(t, valuesEqual(true, 1))
+}
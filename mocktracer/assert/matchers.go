@@ -0,0 +1,207 @@
+package assert
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// Matcher checks a single property of a finished span.
+type Matcher interface {
+	// Match returns nil if the span matches, or an error describing why it
+	// does not.
+	Match(span *mocktracer.MockSpan) error
+
+	// String describes what the Matcher expects.
+	String() string
+}
+
+type matcher struct {
+	desc  string
+	match func(span *mocktracer.MockSpan) error
+}
+
+func (m matcher) Match(span *mocktracer.MockSpan) error { return m.match(span) }
+func (m matcher) String() string                        { return m.desc }
+
+// MatcherFunc returns a Matcher described by `desc` that matches spans for
+// which `fn` returns true.
+func MatcherFunc(desc string, fn func(span *mocktracer.MockSpan) bool) Matcher {
+	return matcher{desc, func(span *mocktracer.MockSpan) error {
+		if !fn(span) {
+			return fmt.Errorf("not %s", desc)
+		}
+		return nil
+	}}
+}
+
+// OperationName matches spans with the given operation name.
+func OperationName(name string) Matcher {
+	return matcher{fmt.Sprintf("operation name %q", name), func(span *mocktracer.MockSpan) error {
+		if span.OperationName != name {
+			return fmt.Errorf("operation name is %q", span.OperationName)
+		}
+		return nil
+	}}
+}
+
+// Root matches spans without a parent.
+func Root() Matcher {
+	return matcher{"root", func(span *mocktracer.MockSpan) error {
+		if span.ParentID != 0 {
+			return fmt.Errorf("has parent %d", span.ParentID)
+		}
+		return nil
+	}}
+}
+
+// ChildOf matches spans started with a ChildOf reference to `parent`.
+func ChildOf(parent opentracing.Span) Matcher {
+	return referenceMatcher("child of", opentracing.ChildOfRef, parent)
+}
+
+// FollowsFrom matches spans started with a FollowsFrom reference to
+// `parent`.
+func FollowsFrom(parent opentracing.Span) Matcher {
+	return referenceMatcher("follows from", opentracing.FollowsFromRef, parent)
+}
+
+// LinkedTo matches spans started with a Link reference to `span`.
+func LinkedTo(span opentracing.Span) Matcher {
+	return referenceMatcher("linked to", opentracing.LinkRef, span)
+}
+
+func referenceMatcher(verb string, refType opentracing.SpanReferenceType, target opentracing.Span) Matcher {
+	if ms, ok := target.(*mocktracer.MockSpan); target == nil || ok && ms == nil {
+		// Typically the result of a failed HasSpan; that failure was
+		// already reported.
+		return matcher{verb + " <nil>", func(*mocktracer.MockSpan) error {
+			return fmt.Errorf("not %s <nil>", verb)
+		}}
+	}
+	targetCtx, _ := target.Context().(mocktracer.MockSpanContext)
+	desc := fmt.Sprintf("%s span %d", verb, targetCtx.SpanID)
+	if ms, ok := target.(*mocktracer.MockSpan); ok {
+		desc = fmt.Sprintf("%s %q", verb, ms.OperationName)
+	}
+	return matcher{desc, func(span *mocktracer.MockSpan) error {
+		for _, ref := range span.References() {
+			sc, ok := ref.ReferencedContext.(mocktracer.MockSpanContext)
+			if ok && ref.Type == refType && sc.SpanID == targetCtx.SpanID {
+				return nil
+			}
+		}
+		return fmt.Errorf("not %s", desc)
+	}}
+}
+
+// Tag matches spans with the tag `key` set to `value`. Numeric values are
+// compared by value regardless of their type, so Tag("http.status_code",
+// 200) matches a uint16(200) tag.
+func Tag(key string, value interface{}) Matcher {
+	return matcher{fmt.Sprintf("tag %s=%v", key, value), func(span *mocktracer.MockSpan) error {
+		got, ok := span.Tags()[key]
+		if !ok {
+			return fmt.Errorf("tag %s is not set", key)
+		}
+		if !valuesEqual(got, value) {
+			return fmt.Errorf("tag %s=%v", key, got)
+		}
+		return nil
+	}}
+}
+
+// HasTag matches spans with the tag `key` set to any value.
+func HasTag(key string) Matcher {
+	return matcher{fmt.Sprintf("tag %s", key), func(span *mocktracer.MockSpan) error {
+		if _, ok := span.Tags()[key]; !ok {
+			return fmt.Errorf("tag %s is not set", key)
+		}
+		return nil
+	}}
+}
+
+// LoggedEvent matches spans with a log record whose "event" field is
+// `event`.
+func LoggedEvent(event string) Matcher {
+	return LoggedField("event", event)
+}
+
+// LoggedField matches spans with a log record containing the field `key`
+// with `value`. Values are compared by their fmt.Sprint() representation,
+// since that is how MockKeyValue stores them.
+func LoggedField(key string, value interface{}) Matcher {
+	want := fmt.Sprint(value)
+	return matcher{fmt.Sprintf("logged %s=%s", key, want), func(span *mocktracer.MockSpan) error {
+		for _, rec := range span.Logs() {
+			for _, f := range rec.Fields {
+				if f.Key == key && f.ValueString == want {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no log with %s=%s", key, want)
+	}}
+}
+
+// matchAll returns the mismatches of `span` against all matchers.
+func matchAll(span *mocktracer.MockSpan, matchers []Matcher) []string {
+	var errs []string
+	for _, m := range matchers {
+		if err := m.Match(span); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+func describe(matchers []Matcher) string {
+	if len(matchers) == 0 {
+		return "any span"
+	}
+	descs := make([]string, len(matchers))
+	for i, m := range matchers {
+		descs[i] = m.String()
+	}
+	return strings.Join(descs, ", ")
+}
+
+func valuesEqual(got, want interface{}) bool {
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+	g, ok1 := number(got)
+	w, ok2 := number(want)
+	return ok1 && ok2 && g == w
+}
+
+func number(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// formatTags renders tags sorted by key.
+func formatTags(tags map[string]interface{}) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, tags[k])
+	}
+	return strings.Join(parts, " ")
+}
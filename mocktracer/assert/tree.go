@@ -0,0 +1,154 @@
+package assert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// forest links finished spans to their children. Orphans, whose parent did
+// not finish, are treated as roots.
+type forest struct {
+	roots    []*mocktracer.MockSpan
+	children map[*mocktracer.MockSpan][]*mocktracer.MockSpan
+}
+
+func newForest(traces []*mocktracer.MockTrace) *forest {
+	f := &forest{children: make(map[*mocktracer.MockSpan][]*mocktracer.MockSpan)}
+	var add func(n *mocktracer.MockSpanNode)
+	add = func(n *mocktracer.MockSpanNode) {
+		for _, c := range n.Children {
+			f.children[n.Span] = append(f.children[n.Span], c.Span)
+			add(c)
+		}
+	}
+	for _, tr := range traces {
+		for _, n := range append(append([]*mocktracer.MockSpanNode(nil), tr.Roots...), tr.Orphans...) {
+			f.roots = append(f.roots, n.Span)
+			add(n)
+		}
+	}
+	return f
+}
+
+// diff compares the expected node with the recorded span and returns the
+// number of mismatches: failed matchers, matchers of missing spans, and
+// unexpected spans. If b is not nil, it also writes the diff to b.
+func (f *forest) diff(b *strings.Builder, exp *SpanNode, act *mocktracer.MockSpan, depth int) int {
+	indent := strings.Repeat("  ", depth)
+	n := 0
+	if errs := matchAll(act, exp.matchers); len(errs) > 0 {
+		n += len(errs)
+		if b != nil {
+			fmt.Fprintf(b, "- %s%s\n", indent, describe(exp.matchers))
+			fmt.Fprintf(b, "+ %s%s  (%s)\n", indent, formatSpan(act), strings.Join(errs, "; "))
+		}
+	} else if b != nil {
+		fmt.Fprintf(b, "  %s%s\n", indent, formatSpan(act))
+	}
+
+	// Pair each expected child with a recorded child: first with one whose
+	// whole subtree matches, then with the one whose own span matches the
+	// most matchers, if any.
+	children := f.children[act]
+	paired := make([]*mocktracer.MockSpan, len(exp.children))
+	used := make([]bool, len(children))
+	for i, ec := range exp.children {
+		for j, ac := range children {
+			if !used[j] && f.diff(nil, ec, ac, 0) == 0 {
+				paired[i], used[j] = ac, true
+				break
+			}
+		}
+	}
+	for i, ec := range exp.children {
+		if paired[i] != nil {
+			continue
+		}
+		best, bestErrs := -1, len(ec.matchers)
+		for j, ac := range children {
+			if errs := len(matchAll(ac, ec.matchers)); !used[j] && errs < bestErrs {
+				best, bestErrs = j, errs
+			}
+		}
+		if best >= 0 {
+			paired[i], used[best] = children[best], true
+		}
+	}
+
+	for i, ec := range exp.children {
+		if paired[i] != nil {
+			n += f.diff(b, ec, paired[i], depth+1)
+			continue
+		}
+		n += expectedSize(ec)
+		if b != nil {
+			writeExpected(b, ec, depth+1)
+		}
+	}
+	for j, ac := range children {
+		if used[j] {
+			continue
+		}
+		n += f.recordedSize(ac)
+		if b != nil {
+			f.writeRecorded(b, ac, depth+1)
+		}
+	}
+	return n
+}
+
+func expectedSize(exp *SpanNode) int {
+	n := len(exp.matchers)
+	if n == 0 {
+		n = 1
+	}
+	for _, c := range exp.children {
+		n += expectedSize(c)
+	}
+	return n
+}
+
+func (f *forest) recordedSize(act *mocktracer.MockSpan) int {
+	n := 1
+	for _, c := range f.children[act] {
+		n += f.recordedSize(c)
+	}
+	return n
+}
+
+func writeExpected(b *strings.Builder, exp *SpanNode, depth int) {
+	fmt.Fprintf(b, "- %s%s\n", strings.Repeat("  ", depth), describe(exp.matchers))
+	for _, c := range exp.children {
+		writeExpected(b, c, depth+1)
+	}
+}
+
+func (f *forest) writeRecorded(b *strings.Builder, act *mocktracer.MockSpan, depth int) {
+	fmt.Fprintf(b, "+ %s%s\n", strings.Repeat("  ", depth), formatSpan(act))
+	for _, c := range f.children[act] {
+		f.writeRecorded(b, c, depth+1)
+	}
+}
+
+// formatSpan renders the operation name, tags and logged events of a span
+// on one line.
+func formatSpan(sp *mocktracer.MockSpan) string {
+	s := sp.OperationName
+	if tags := sp.Tags(); len(tags) > 0 {
+		s += " [" + formatTags(tags) + "]"
+	}
+	var events []string
+	for _, rec := range sp.Logs() {
+		for _, f := range rec.Fields {
+			if f.Key == "event" {
+				events = append(events, f.ValueString)
+			}
+		}
+	}
+	if len(events) > 0 {
+		s += " {" + strings.Join(events, ", ") + "}"
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+// Package golden compares the traces recorded by a mocktracer.MockTracer
+// against golden files checked in with the tests:
+//
+//     func TestHandler(t *testing.T) {
+//         tracer := mocktracer.New()
+//         ... exercise the instrumented code ...
+//         golden.AssertTraces(t, "testdata/handler.golden", tracer, mocktracer.SnapshotEncoder{OmitTimings: true})
+//     }
+//
+// The traces are serialized with mocktracer.SnapshotEncoder. To create or
+// regenerate the golden files after an intended change, run the tests with
+// the MOCKTRACER_UPDATE_GOLDEN environment variable set:
+//
+//     MOCKTRACER_UPDATE_GOLDEN=1 go test ./...
+package golden
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// UpdateEnv is the environment variable that makes Assert and AssertTraces
+// write golden files instead of comparing against them, when set to a
+// non-empty value. An environment variable rather than a flag keeps this
+// package from registering flags in the programs that import it.
+const UpdateEnv = "MOCKTRACER_UPDATE_GOLDEN"
+
+func update() bool {
+	return os.Getenv(UpdateEnv) != ""
+}
+
+// TestingT is the subset of testing.T used to report failures.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertTraces asserts that the finished spans of `tracer`, serialized with
+// `enc`, match the golden file at `path`. If UpdateEnv is set, it writes the
+// golden file instead.
+func AssertTraces(t TestingT, path string, tracer *mocktracer.MockTracer, enc mocktracer.SnapshotEncoder) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	return Assert(t, path, enc.Encode(tracer.Traces()))
+}
+
+// Assert asserts that `got` matches the golden file at `path`. If UpdateEnv
+// is set, it writes the golden file instead, creating its directory if
+// needed.
+func Assert(t TestingT, path string, got []byte) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if update() {
+		if err := write(path, got); err != nil {
+			t.Errorf("cannot update golden file: %v", err)
+			return false
+		}
+		return true
+	}
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Errorf("golden file %s does not exist; run the test with %s=1 to create it", path, UpdateEnv)
+		return false
+	} else if err != nil {
+		t.Errorf("cannot read golden file: %v", err)
+		return false
+	}
+	if bytes.Equal(want, got) {
+		return true
+	}
+	t.Errorf("recorded traces do not match golden file %s (- golden, + recorded); run the test with %s=1 to accept them:\n%s",
+		path, UpdateEnv, diff(string(want), string(got)))
+	return false
+}
+
+func write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// diff returns a line diff of `a` and `b` based on their longest common
+// subsequence of lines.
+func diff(a, b string) string {
+	x := strings.SplitAfter(a, "\n")
+	y := strings.SplitAfter(b, "\n")
+	// lcs[i][j] is the length of the LCS of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var out strings.Builder
+	emit := func(prefix, line string) {
+		if line == "" {
+			return
+		}
+		out.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			emit("  ", x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			emit("- ", x[i])
+			i++
+		default:
+			emit("+ ", y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		emit("- ", x[i])
+	}
+	for ; j < len(y); j++ {
+		emit("+ ", y[j])
+	}
+	return out.String()
+}
@@ -0,0 +1,86 @@
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type recordingT struct {
+	failures []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func recordRequest() *mocktracer.MockTracer {
+	tracer := mocktracer.New()
+	root := tracer.StartSpan("GET /x", opentracing.Tag{Key: "http.status_code", Value: 200})
+	tracer.StartSpan("render", opentracing.ChildOf(root.Context())).Finish()
+	tracer.StartSpan("SELECT", opentracing.ChildOf(root.Context())).Finish()
+	root.Finish()
+	return tracer
+}
+
+func TestAssertTraces(t *testing.T) {
+	AssertTraces(t, "testdata/request.golden", recordRequest(), mocktracer.SnapshotEncoder{OmitTimings: true})
+}
+
+func TestAssertMismatch(t *testing.T) {
+	tracer := recordRequest()
+	tracer.StartSpan("extra").Finish()
+
+	rt := &recordingT{}
+	assert.False(t, AssertTraces(rt, "testdata/request.golden", tracer, mocktracer.SnapshotEncoder{OmitTimings: true}))
+	require.Len(t, rt.failures, 1)
+	assert.Equal(t, `recorded traces do not match golden file testdata/request.golden (- golden, + recorded); run the test with MOCKTRACER_UPDATE_GOLDEN=1 to accept them:
+  trace 1
+    span 1 "GET /x"
+      tag http.status_code=200
+      span 2 "SELECT"
+        child_of span 1
+      span 3 "render"
+        child_of span 1
++ trace 2
++   span 4 "extra"
+`, rt.failures[0])
+
+	rt = &recordingT{}
+	assert.False(t, Assert(rt, "testdata/missing.golden", nil))
+	require.Len(t, rt.failures, 1)
+	assert.Contains(t, rt.failures[0], "does not exist")
+}
+
+func TestUpdate(t *testing.T) {
+	if old, ok := os.LookupEnv(UpdateEnv); ok {
+		defer os.Setenv(UpdateEnv, old)
+	} else {
+		defer os.Unsetenv(UpdateEnv)
+	}
+	require.NoError(t, os.Setenv(UpdateEnv, "1"))
+	dir, err := ioutil.TempDir("", "golden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "out.golden")
+
+	rt := &recordingT{}
+	assert.True(t, Assert(rt, path, []byte("a\nb\n")))
+	assert.Empty(t, rt.failures)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", string(data))
+
+	require.NoError(t, os.Unsetenv(UpdateEnv))
+	assert.False(t, Assert(rt, path, []byte("a\nc\n")))
+	require.Len(t, rt.failures, 1)
+	assert.Contains(t, rt.failures[0], "  a\n- b\n+ c\n")
+}
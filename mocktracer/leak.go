@@ -0,0 +1,89 @@
+package mocktracer
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// TestingT is the subset of testing.TB used by VerifyNoUnfinishedSpans.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+// VerifyNoUnfinishedSpans returns a function that fails the test if any
+// span of `tracers` is still unfinished when it is called, typically
+// deferred until the test completes. Without tracers, it verifies the
+// current global tracer, which must be a *MockTracer. For example:
+//
+//     func TestHandler(t *testing.T) {
+//         tracer := mocktracer.New(mocktracer.WithStartStacks())
+//         defer mocktracer.VerifyNoUnfinishedSpans(t, tracer)()
+//         ...
+//     }
+//
+// The failure lists every unfinished span along with the stack it was
+// started from, if the tracer was created with WithStartStacks().
+func VerifyNoUnfinishedSpans(t TestingT, tracers ...*MockTracer) (check func()) {
+	t.Helper()
+	if len(tracers) == 0 {
+		tracer, ok := opentracing.GlobalTracer().(*MockTracer)
+		if !ok {
+			t.Errorf("mocktracer: global tracer is %T, not *MockTracer", opentracing.GlobalTracer())
+			return func() {}
+		}
+		tracers = []*MockTracer{tracer}
+	}
+	return func() {
+		t.Helper()
+		var unfinished []*MockSpan
+		for _, tracer := range tracers {
+			unfinished = append(unfinished, tracer.UnfinishedSpans()...)
+		}
+		if len(unfinished) == 0 {
+			return
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "mocktracer: found %d unfinished spans:\n", len(unfinished))
+		for _, sp := range unfinished {
+			sc := sp.Context().(MockSpanContext)
+			fmt.Fprintf(&b, "\nspan %q (traceId=%d, spanId=%d)", sp.OperationName, sc.TraceID, sc.SpanID)
+			if stack := sp.StartStack(); stack != "" {
+				b.WriteString(" started at:\n" + stack)
+			} else {
+				b.WriteString(": start stack not captured, create the tracer with mocktracer.WithStartStacks()\n")
+			}
+		}
+		t.Errorf("%s", b.String())
+	}
+}
+
+// StartStack returns the stack of the caller of StartSpan(), formatted like
+// a goroutine trace, or "" if the tracer was not created with
+// WithStartStacks().
+func (s *MockSpan) StartStack() string {
+	if len(s.startStack) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(s.startStack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// captureStack returns the program counters of the stack, skipping `skip`
+// frames as runtime.Callers() does, not counting captureStack itself.
+func captureStack(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	return pcs[:n]
+}
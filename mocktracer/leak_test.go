@@ -0,0 +1,78 @@
+package mocktracer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+type recordingT struct {
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyNoUnfinishedSpans(t *testing.T) {
+	tracer := New(WithStartStacks())
+	defer VerifyNoUnfinishedSpans(t, tracer)()
+	tracer.StartSpan("finished").Finish()
+}
+
+func TestVerifyNoUnfinishedSpansFailure(t *testing.T) {
+	rt := &recordingT{}
+	tracer := New(WithStartStacks())
+	check := VerifyNoUnfinishedSpans(rt, tracer)
+	tracer.StartSpan("finished").Finish()
+	leaked := tracer.StartSpan("leaked").(*MockSpan)
+	check()
+
+	require.Len(t, rt.failures, 1)
+	msg := rt.failures[0]
+	assert.True(t, strings.HasPrefix(msg, "mocktracer: found 1 unfinished spans:\n\nspan \"leaked\""), msg)
+	assert.Contains(t, msg, "started at:\n"+leaked.StartStack())
+	assert.True(t, strings.HasPrefix(leaked.StartStack(),
+		"github.com/opentracing/opentracing-go/mocktracer.TestVerifyNoUnfinishedSpansFailure\n"), leaked.StartStack())
+	assert.NotContains(t, msg, "finished\"")
+}
+
+func TestVerifyNoUnfinishedSpansWithoutStacks(t *testing.T) {
+	rt := &recordingT{}
+	tracer := New()
+	check := VerifyNoUnfinishedSpans(rt, tracer)
+	span := tracer.StartSpan("leaked").(*MockSpan)
+	assert.Empty(t, span.StartStack())
+	check()
+
+	require.Len(t, rt.failures, 1)
+	assert.Contains(t, rt.failures[0], "start stack not captured")
+}
+
+func TestVerifyNoUnfinishedSpansGlobalTracer(t *testing.T) {
+	rt := &recordingT{}
+	tracer := New()
+	var check func()
+	opentracing.WithGlobalTracer(tracer, func() {
+		check = VerifyNoUnfinishedSpans(rt)
+	})
+	tracer.StartSpan("leaked")
+	check()
+	assert.Len(t, rt.failures, 1)
+
+	rt = &recordingT{}
+	opentracing.WithGlobalTracer(opentracing.NoopTracer{}, func() {
+		check = VerifyNoUnfinishedSpans(rt)
+	})
+	require.Len(t, rt.failures, 1)
+	assert.Equal(t, "mocktracer: global tracer is opentracing.NoopTracer, not *MockTracer", rt.failures[0])
+	check()
+	assert.Len(t, rt.failures, 1)
+}
@@ -2,6 +2,7 @@ package mocktracer
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,7 +18,10 @@ import (
 // that want to verify tracing behavior in other frameworks/applications.
 //
 // By default all spans have Sampled=true flag, unless {"sampling.priority": 0}
-// tag is set.
+// tag is set, either at start or with SetTag(). Like other tracers, MockTracer
+// applies the sampling priority to the span context instead of recording it
+// as a tag, so that shared samplers (see package sampling) can decide at
+// start.
 type MockSpanContext struct {
 	TraceID int
 	SpanID  int
@@ -56,11 +60,23 @@ func (c MockSpanContext) WithBaggageItem(key, value string) MockSpanContext {
 	return MockSpanContext{c.TraceID, c.SpanID, c.Sampled, newBaggage}
 }
 
+// TraceIDString returns the trace ID in decimal.
+func (c MockSpanContext) TraceIDString() string {
+	return strconv.Itoa(c.TraceID)
+}
+
+// SpanIDString returns the span ID in decimal.
+func (c MockSpanContext) SpanIDString() string {
+	return strconv.Itoa(c.SpanID)
+}
+
 // MockSpan is an opentracing.Span implementation that exports its internal
 // state for testing purposes.
 type MockSpan struct {
 	sync.RWMutex
 
+	// ParentID is the SpanID of the parent reference, preferring ChildOf
+	// over FollowsFrom, or 0 for a root span. See References().
 	ParentID int
 
 	OperationName string
@@ -71,6 +87,8 @@ type MockSpan struct {
 	SpanContext MockSpanContext
 	tags        map[string]interface{}
 	logs        []MockLogRecord
+	references  []opentracing.AttributedReference
+	startStack  []uintptr
 	tracer      *MockTracer
 }
 
@@ -79,20 +97,29 @@ func newMockSpan(t *MockTracer, name string, opts opentracing.StartSpanOptions)
 	if tags == nil {
 		tags = map[string]interface{}{}
 	}
-	traceID := nextMockID()
+	traceID := t.nextID()
 	parentID := int(0)
-	var baggage map[string]string
 	sampled := true
-	if len(opts.References) > 0 {
-		traceID = opts.References[0].ReferencedContext.(MockSpanContext).TraceID
-		parentID = opts.References[0].ReferencedContext.(MockSpanContext).SpanID
-		sampled = opts.References[0].ReferencedContext.(MockSpanContext).Sampled
-		baggage = opts.References[0].ReferencedContext.(MockSpanContext).Baggage
+	parent, hasParent := parentContext(opts.References)
+	if hasParent {
+		traceID = parent.TraceID
+		parentID = parent.SpanID
+		sampled = parent.Sampled
+	}
+	baggage := mergeBaggage(opts.References, parent)
+	// As in SetTag(), a sampling priority is applied rather than recorded.
+	switch v := tags[string(ext.SamplingPriority)].(type) {
+	case uint16:
+		sampled = v > 0
+		delete(tags, string(ext.SamplingPriority))
+	case int:
+		sampled = v > 0
+		delete(tags, string(ext.SamplingPriority))
 	}
-	spanContext := MockSpanContext{traceID, nextMockID(), sampled, baggage}
+	spanContext := MockSpanContext{traceID, t.nextID(), sampled, baggage}
 	startTime := opts.StartTime
 	if startTime.IsZero() {
-		startTime = time.Now()
+		startTime = t.now()
 	}
 	return &MockSpan{
 		ParentID:      parentID,
@@ -100,12 +127,62 @@ func newMockSpan(t *MockTracer, name string, opts opentracing.StartSpanOptions)
 		StartTime:     startTime,
 		tags:          tags,
 		logs:          []MockLogRecord{},
+		references:    opts.AttributedReferences(),
 		SpanContext:   spanContext,
 
 		tracer: t,
 	}
 }
 
+// parentContext chooses the parent among the references: the first ChildOf
+// reference to a MockSpanContext, or else the first such FollowsFrom
+// reference. Links, nil and foreign contexts are never chosen.
+func parentContext(refs []opentracing.SpanReference) (MockSpanContext, bool) {
+	var followsFrom *MockSpanContext
+	for _, ref := range refs {
+		sc, ok := ref.ReferencedContext.(MockSpanContext)
+		if !ok {
+			continue
+		}
+		switch ref.Type {
+		case opentracing.ChildOfRef:
+			return sc, true
+		case opentracing.FollowsFromRef:
+			if followsFrom == nil {
+				followsFrom = &sc
+			}
+		}
+	}
+	if followsFrom != nil {
+		return *followsFrom, true
+	}
+	return MockSpanContext{}, false
+}
+
+// mergeBaggage merges the baggage of all ChildOf and FollowsFrom references,
+// including foreign contexts. On conflicting keys the parent's value wins,
+// then the earlier reference's. Links do not contribute baggage.
+func mergeBaggage(refs []opentracing.SpanReference, parent MockSpanContext) map[string]string {
+	var baggage map[string]string
+	add := func(k, v string) bool {
+		if baggage == nil {
+			baggage = make(map[string]string)
+		}
+		if _, ok := baggage[k]; !ok {
+			baggage[k] = v
+		}
+		return true
+	}
+	parent.ForeachBaggageItem(add)
+	for _, ref := range refs {
+		if ref.ReferencedContext == nil || ref.Type == opentracing.LinkRef {
+			continue
+		}
+		ref.ReferencedContext.ForeachBaggageItem(add)
+	}
+	return baggage
+}
+
 // Tags returns a copy of tags accumulated by the span so far
 func (s *MockSpan) Tags() map[string]interface{} {
 	s.RLock()
@@ -133,6 +210,13 @@ func (s *MockSpan) Logs() []MockLogRecord {
 	return logs
 }
 
+// References returns a copy of all references the span was started with,
+// in the order they were given, with their types and attributes. Unlike
+// ParentID, it includes references to foreign (non-mock) span contexts.
+func (s *MockSpan) References() []opentracing.AttributedReference {
+	return append([]opentracing.AttributedReference(nil), s.references...)
+}
+
 // Context belongs to the Span interface
 func (s *MockSpan) Context() opentracing.SpanContext {
 	s.Lock()
@@ -176,7 +260,7 @@ func (s *MockSpan) BaggageItem(key string) string {
 // Finish belongs to the Span interface
 func (s *MockSpan) Finish() {
 	s.Lock()
-	s.FinishTime = time.Now()
+	s.FinishTime = s.tracer.now()
 	s.Unlock()
 	s.tracer.recordFinishedSpan(s)
 }
@@ -218,7 +302,7 @@ func (s *MockSpan) String() string {
 
 // LogFields belongs to the Span interface
 func (s *MockSpan) LogFields(fields ...log.Field) {
-	s.logFieldsWithTimestamp(time.Now(), fields...)
+	s.logFieldsWithTimestamp(s.tracer.now(), fields...)
 }
 
 // The caller MUST NOT hold s.Lock
@@ -267,7 +351,8 @@ func (s *MockSpan) LogEventWithPayload(event string, payload interface{}) {
 
 // Log belongs to the Span interface
 func (s *MockSpan) Log(data opentracing.LogData) {
-	panic("MockSpan.Log() no longer supported")
+	lr := data.ToLogRecord()
+	s.logFieldsWithTimestamp(lr.Timestamp, lr.Fields...)
 }
 
 // SetOperationName belongs to the Span interface
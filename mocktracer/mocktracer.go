@@ -2,19 +2,31 @@ package mocktracer
 
 import (
 	"sync"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 )
 
 // New returns a MockTracer opentracing.Tracer implementation that's intended
 // to facilitate tests of OpenTracing instrumentation.
-func New() *MockTracer {
+//
+// Without options, IDs come from a sequence shared by all MockTracers and
+// timestamps from time.Now(). Use WithIDGenerator and WithClock for
+// reproducible output:
+//
+//     tracer := mocktracer.New(
+//         mocktracer.WithIDGenerator(mocktracer.SequentialIDs(1)),
+//         mocktracer.WithClock(mocktracer.NewManualClock(time.Unix(0, 0))))
+func New(opts ...Option) *MockTracer {
 	t := &MockTracer{
 		finishedSpans: []*MockSpan{},
 		startedSpans:  []*MockSpan{},
 		injectors:     make(map[interface{}]Injector),
 		extractors:    make(map[interface{}]Extractor),
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
 
 	// register default injectors/extractors
 	textPropagator := new(TextMapPropagator)
@@ -25,6 +37,10 @@ func New() *MockTracer {
 	t.RegisterInjector(opentracing.HTTPHeaders, httpPropagator)
 	t.RegisterExtractor(opentracing.HTTPHeaders, httpPropagator)
 
+	binaryPropagator := new(BinaryPropagator)
+	t.RegisterInjector(opentracing.Binary, binaryPropagator)
+	t.RegisterExtractor(opentracing.Binary, binaryPropagator)
+
 	return t
 }
 
@@ -38,6 +54,29 @@ type MockTracer struct {
 	startedSpans  []*MockSpan
 	injectors     map[interface{}]Injector
 	extractors    map[interface{}]Extractor
+	finished      chan struct{} // closed when the next span finishes
+	subscriptions []*finishSubscription
+	resets        int
+
+	// Set at construction only, hence not protected by the RWMutex. Nil
+	// means the defaults, see New().
+	ids         IDGenerator
+	clock       Clock
+	startStacks bool
+}
+
+func (t *MockTracer) nextID() int {
+	if t.ids == nil {
+		return nextMockID()
+	}
+	return t.ids.NextID()
+}
+
+func (t *MockTracer) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock.Now()
 }
 
 // UnfinishedSpans returns all spans that have been started and not finished since the
@@ -69,6 +108,7 @@ func (t *MockTracer) Reset() {
 	defer t.Unlock()
 	t.startedSpans = []*MockSpan{}
 	t.finishedSpans = []*MockSpan{}
+	t.resets++
 }
 
 // StartSpan belongs to the Tracer interface.
@@ -79,6 +119,9 @@ func (t *MockTracer) StartSpan(operationName string, opts ...opentracing.StartSp
 	}
 
 	span := newMockSpan(t, operationName, sso)
+	if t.startStacks {
+		span.startStack = captureStack(2)
+	}
 	t.recordStartedSpan(span)
 	return span
 }
@@ -112,7 +155,11 @@ func (t *MockTracer) Extract(format interface{}, carrier interface{}) (opentraci
 	if !ok {
 		return nil, opentracing.ErrUnsupportedFormat
 	}
-	return extractor.Extract(carrier)
+	spanContext, err := extractor.Extract(carrier)
+	if err != nil {
+		return nil, err
+	}
+	return spanContext, nil
 }
 
 func (t *MockTracer) recordStartedSpan(span *MockSpan) {
@@ -123,14 +170,15 @@ func (t *MockTracer) recordStartedSpan(span *MockSpan) {
 
 func (t *MockTracer) recordFinishedSpan(span *MockSpan) {
 	t.Lock()
-	defer t.Unlock()
 	t.finishedSpans = append(t.finishedSpans, span)
 
 	for i := range t.startedSpans {
 		if t.startedSpans[i].SpanContext.SpanID == span.SpanContext.SpanID &&
 			t.startedSpans[i].SpanContext.TraceID == span.SpanContext.TraceID {
 			t.startedSpans = append(t.startedSpans[:i], t.startedSpans[i+1:]...)
-			return
+			break
 		}
 	}
+	t.notifyFinished(span)
+	t.Unlock()
 }
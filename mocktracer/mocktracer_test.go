@@ -1,6 +1,7 @@
 package mocktracer
 
 import (
+	"bytes"
 	"net/http"
 	"reflect"
 	"sync"
@@ -36,6 +37,133 @@ func TestMockTracer_StartSpan(t *testing.T) {
 	assert.Equal(t, child.ParentID, parent.Context().(MockSpanContext).SpanID)
 }
 
+func TestMockSpan_References(t *testing.T) {
+	tracer := New()
+	producer1 := tracer.StartSpan("producer1")
+	producer2 := tracer.StartSpan("producer2")
+	parent := tracer.StartSpan("parent")
+
+	span := tracer.StartSpan("batch",
+		opentracing.Link(producer1.Context(), opentracing.Tags{"index": 0}),
+		opentracing.ChildOf(parent.Context()),
+		opentracing.Link(producer2.Context(), opentracing.Tags{"index": 1}),
+	).(*MockSpan)
+
+	refs := span.References()
+	require.Len(t, refs, 3)
+	assert.Equal(t, opentracing.LinkRef, refs[0].Type)
+	assert.Equal(t, producer1.Context(), refs[0].ReferencedContext)
+	assert.Equal(t, opentracing.Tags{"index": 0}, refs[0].Attributes)
+	assert.Equal(t, opentracing.ChildOfRef, refs[1].Type)
+	assert.Nil(t, refs[1].Attributes)
+	assert.Equal(t, opentracing.Tags{"index": 1}, refs[2].Attributes)
+
+	parentCtx := parent.Context().(MockSpanContext)
+	assert.Equal(t, parentCtx.SpanID, span.ParentID, "links must not be used as parent")
+	assert.Equal(t, parentCtx.TraceID, span.SpanContext.TraceID)
+
+	linkOnly := tracer.StartSpan("linked", opentracing.Link(producer1.Context(), nil)).(*MockSpan)
+	assert.Equal(t, 0, linkOnly.ParentID)
+	assert.NotEqual(t, producer1.Context().(MockSpanContext).TraceID, linkOnly.SpanContext.TraceID)
+	assert.Empty(t, parent.(*MockSpan).References())
+}
+
+// foreignSpanContext is a SpanContext of another tracer implementation.
+type foreignSpanContext map[string]string
+
+func (c foreignSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+func TestMockSpan_ParentSelection(t *testing.T) {
+	tracer := New()
+	first := tracer.StartSpan("first")
+	first.SetBaggageItem("shared", "first").SetBaggageItem("first", "1")
+	second := tracer.StartSpan("second")
+	second.SetBaggageItem("shared", "second").SetBaggageItem("second", "2")
+	linked := tracer.StartSpan("linked")
+	linked.SetBaggageItem("linked", "3")
+	foreign := foreignSpanContext{"foreign": "4", "shared": "foreign"}
+
+	tests := []struct {
+		name    string
+		refs    []opentracing.SpanReference
+		parent  opentracing.Span
+		baggage map[string]string
+	}{
+		{
+			name: "ChildOf preferred over earlier FollowsFrom",
+			refs: []opentracing.SpanReference{
+				opentracing.FollowsFrom(first.Context()),
+				opentracing.ChildOf(second.Context()),
+			},
+			parent:  second,
+			baggage: map[string]string{"shared": "second", "first": "1", "second": "2"},
+		},
+		{
+			name: "first FollowsFrom without ChildOf",
+			refs: []opentracing.SpanReference{
+				opentracing.Link(linked.Context(), nil).SpanReference,
+				opentracing.FollowsFrom(first.Context()),
+				opentracing.FollowsFrom(second.Context()),
+			},
+			parent:  first,
+			baggage: map[string]string{"shared": "first", "first": "1", "second": "2"},
+		},
+		{
+			name: "foreign ChildOf is skipped but contributes baggage",
+			refs: []opentracing.SpanReference{
+				opentracing.ChildOf(foreign),
+				opentracing.ChildOf(second.Context()),
+			},
+			parent:  second,
+			baggage: map[string]string{"shared": "second", "second": "2", "foreign": "4"},
+		},
+		{
+			name: "only foreign and nil contexts",
+			refs: []opentracing.SpanReference{
+				{Type: opentracing.ChildOfRef, ReferencedContext: nil},
+				opentracing.FollowsFrom(foreign),
+			},
+			baggage: map[string]string{"shared": "foreign", "foreign": "4"},
+		},
+		{
+			name: "only links",
+			refs: []opentracing.SpanReference{opentracing.Link(linked.Context(), nil).SpanReference},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			span := tracer.StartSpan("span", startSpanOptions{References: test.refs}).(*MockSpan)
+			refs := span.References()
+			require.Len(t, refs, len(test.refs))
+			for i, ref := range refs {
+				assert.Equal(t, test.refs[i], ref.SpanReference)
+			}
+			assert.Equal(t, test.baggage, span.SpanContext.Baggage)
+			if test.parent == nil {
+				assert.Equal(t, 0, span.ParentID)
+				return
+			}
+			parentCtx := test.parent.Context().(MockSpanContext)
+			assert.Equal(t, parentCtx.SpanID, span.ParentID)
+			assert.Equal(t, parentCtx.TraceID, span.SpanContext.TraceID)
+		})
+	}
+}
+
+// startSpanOptions applies a StartSpanOptions as is, bypassing the nil
+// checks of the SpanReference options.
+type startSpanOptions opentracing.StartSpanOptions
+
+func (o startSpanOptions) Apply(opts *opentracing.StartSpanOptions) {
+	opts.References = append(opts.References, o.References...)
+}
+
 func TestMockSpan_SetOperationName(t *testing.T) {
 	tracer := New()
 	span := tracer.StartSpan("")
@@ -81,6 +209,25 @@ func TestMockSpan_Tags(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{"x": "y"}, span.(*MockSpan).Tags())
 }
 
+func TestMockTracer_SamplingPriorityStartTag(t *testing.T) {
+	tracer := New()
+	unsampled := tracer.StartSpan("x", opentracing.Tags{
+		string(ext.SamplingPriority): uint16(0),
+		"k":                          "v",
+	}).(*MockSpan)
+	assert.False(t, unsampled.SpanContext.Sampled)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, unsampled.Tags(),
+		"as with SetTag, the priority is applied rather than recorded")
+
+	child := tracer.StartSpan("y", opentracing.ChildOf(unsampled.Context())).(*MockSpan)
+	assert.False(t, child.SpanContext.Sampled)
+	forced := tracer.StartSpan("z",
+		opentracing.ChildOf(unsampled.Context()),
+		opentracing.Tag{Key: string(ext.SamplingPriority), Value: 1}).(*MockSpan)
+	assert.True(t, forced.SpanContext.Sampled)
+	assert.Empty(t, forced.Tags())
+}
+
 func TestMockTracer_FinishedSpans_and_Reset(t *testing.T) {
 	tracer := New()
 	span := tracer.StartSpan("x")
@@ -246,7 +393,7 @@ func TestMockTracer_Propagation(t *testing.T) {
 		mSpan := span.(*MockSpan)
 
 		assert.Equal(t, opentracing.ErrUnsupportedFormat,
-			tracer.Inject(span.Context(), opentracing.Binary, nil))
+			tracer.Inject(span.Context(), "unknown-format", nil))
 		assert.Equal(t, opentracing.ErrInvalidCarrier,
 			tracer.Inject(span.Context(), opentracing.TextMap, span))
 
@@ -260,7 +407,7 @@ func TestMockTracer_Propagation(t *testing.T) {
 			assert.Equal(t, "y%3Az", c["Mockpfx-Baggage-X"][0])
 		}
 
-		_, err = tracer.Extract(opentracing.Binary, nil)
+		_, err = tracer.Extract("unknown-format", nil)
 		assert.Equal(t, opentracing.ErrUnsupportedFormat, err)
 		_, err = tracer.Extract(opentracing.TextMap, tracer)
 		assert.Equal(t, opentracing.ErrInvalidCarrier, err)
@@ -274,6 +421,64 @@ func TestMockTracer_Propagation(t *testing.T) {
 	}
 }
 
+func TestMockTracer_BinaryPropagation(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		tracer := New()
+		span := tracer.StartSpan("x")
+		span.SetBaggageItem("x", "y:z")
+		span.SetBaggageItem("empty", "")
+		if !sampled {
+			ext.SamplingPriority.Set(span, 0)
+		}
+		mSpan := span.(*MockSpan)
+
+		assert.Equal(t, opentracing.ErrInvalidCarrier,
+			tracer.Inject(span.Context(), opentracing.Binary, span))
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, tracer.Inject(span.Context(), opentracing.Binary, buf))
+
+		extractedContext, err := tracer.Extract(opentracing.Binary, buf)
+		require.NoError(t, err)
+		assert.Equal(t, mSpan.SpanContext.TraceID, extractedContext.(MockSpanContext).TraceID)
+		assert.Equal(t, mSpan.SpanContext.SpanID, extractedContext.(MockSpanContext).SpanID)
+		assert.Equal(t, sampled, extractedContext.(MockSpanContext).Sampled)
+		assert.Equal(t, map[string]string{"x": "y:z", "empty": ""},
+			extractedContext.(MockSpanContext).Baggage)
+	}
+}
+
+func TestMockTracer_BinaryExtractErrors(t *testing.T) {
+	tracer := New()
+	span := tracer.StartSpan("x")
+	span.SetBaggageItem("x", "y")
+	buf := new(bytes.Buffer)
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.Binary, buf))
+	encoded := buf.Bytes()
+
+	badVersion := append([]byte{}, encoded...)
+	badVersion[0] = 99
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "empty", data: nil, err: opentracing.ErrSpanContextNotFound},
+		{name: "short header", data: encoded[:5], err: opentracing.ErrSpanContextCorrupted},
+		{name: "truncated baggage", data: encoded[:len(encoded)-1], err: opentracing.ErrSpanContextCorrupted},
+		{name: "bad version", data: badVersion, err: opentracing.ErrSpanContextCorrupted},
+	}
+	for _, test := range tests {
+		ctx, err := tracer.Extract(opentracing.Binary, bytes.NewReader(test.data))
+		assert.Equal(t, test.err, err, test.name)
+		assert.Nil(t, ctx, test.name)
+	}
+
+	_, err := tracer.Extract(opentracing.Binary, tracer)
+	assert.Equal(t, opentracing.ErrInvalidCarrier, err)
+}
+
 func TestMockSpan_Races(t *testing.T) {
 	span := New().StartSpan("x")
 	var wg sync.WaitGroup
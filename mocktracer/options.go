@@ -0,0 +1,95 @@
+package mocktracer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures a MockTracer, see New().
+type Option func(*MockTracer)
+
+// IDGenerator generates the trace and span IDs of a MockTracer. It must be
+// safe for concurrent use.
+type IDGenerator interface {
+	NextID() int
+}
+
+// Clock provides the start, finish and log timestamps of a MockTracer, when
+// they are not given explicitly. It must be safe for concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithIDGenerator returns an Option that makes the tracer take its trace and
+// span IDs from `g`. By default, all MockTracers share a single sequence.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(t *MockTracer) {
+		t.ids = g
+	}
+}
+
+// WithClock returns an Option that makes the tracer take timestamps from
+// `c`. By default, time.Now() is used.
+func WithClock(c Clock) Option {
+	return func(t *MockTracer) {
+		t.clock = c
+	}
+}
+
+// WithStartStacks returns an Option that makes the tracer capture the stack
+// of the caller of StartSpan(), see MockSpan.StartStack(). This slows down
+// StartSpan() but points VerifyNoUnfinishedSpans at the leaking code.
+func WithStartStacks() Option {
+	return func(t *MockTracer) {
+		t.startStacks = true
+	}
+}
+
+// SequentialIDs returns an IDGenerator producing first, first+1, and so on.
+// Giving each MockTracer its own sequence makes IDs reproducible even when
+// tests run in parallel.
+func SequentialIDs(first int) IDGenerator {
+	return &sequentialIDs{next: int64(first) - 1}
+}
+
+type sequentialIDs struct {
+	next int64
+}
+
+func (g *sequentialIDs) NextID() int {
+	return int(atomic.AddInt64(&g.next, 1))
+}
+
+// ManualClock is a Clock that only moves when told to.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a ManualClock set to `now`.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now belongs to the Clock interface.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the time of the clock.
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by `d` and returns the new time.
+func (c *ManualClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
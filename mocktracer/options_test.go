@@ -0,0 +1,91 @@
+package mocktracer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+func TestWithIDGenerator(t *testing.T) {
+	tracer := New(WithIDGenerator(SequentialIDs(1)))
+	parent := tracer.StartSpan("parent")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	assert.Equal(t, MockSpanContext{TraceID: 1, SpanID: 2, Sampled: true}, parent.Context())
+	assert.Equal(t, 1, child.Context().(MockSpanContext).TraceID)
+	assert.Equal(t, 4, child.Context().(MockSpanContext).SpanID)
+
+	// Other tracers do not affect the sequence.
+	New().StartSpan("other")
+	New(WithIDGenerator(SequentialIDs(1))).StartSpan("other")
+	assert.Equal(t, 6, tracer.StartSpan("next").Context().(MockSpanContext).SpanID)
+}
+
+func TestSequentialIDsConcurrent(t *testing.T) {
+	ids := SequentialIDs(1)
+	seen := make(chan int, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				seen <- ids.NextID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(seen)
+	unique := map[int]bool{}
+	for id := range seen {
+		unique[id] = true
+	}
+	assert.Len(t, unique, 100)
+	assert.Equal(t, 101, ids.NextID())
+}
+
+func TestWithClock(t *testing.T) {
+	start := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	tracer := New(WithClock(clock))
+
+	span := tracer.StartSpan("op").(*MockSpan)
+	assert.Equal(t, start, span.StartTime)
+	clock.Advance(time.Millisecond)
+	span.LogKV("event", "a")
+	span.LogEvent("b")
+	clock.Advance(time.Millisecond)
+	span.Finish()
+
+	logs := span.Logs()
+	require.Len(t, logs, 2)
+	assert.Equal(t, start.Add(time.Millisecond), logs[0].Timestamp)
+	assert.Equal(t, start.Add(time.Millisecond), logs[1].Timestamp)
+	assert.Equal(t, start.Add(2*time.Millisecond), span.FinishTime)
+
+	explicit := start.Add(-time.Hour)
+	span = tracer.StartSpan("op", opentracing.StartTime(explicit)).(*MockSpan)
+	assert.Equal(t, explicit, span.StartTime)
+
+	clock.Set(start)
+	assert.Equal(t, start, clock.Now())
+}
+
+func TestDefaultOptions(t *testing.T) {
+	before := time.Now()
+	span := New().StartSpan("op").(*MockSpan)
+	span.Finish()
+	assert.False(t, span.StartTime.Before(before))
+	assert.False(t, span.FinishTime.Before(span.StartTime))
+	next := New().StartSpan("op").Context().(MockSpanContext)
+	assert.Equal(t, span.SpanContext.SpanID+2, next.SpanID, "default IDs are shared by all tracers")
+
+	var zero MockTracer
+	span = zero.StartSpan("op").(*MockSpan)
+	span.Finish()
+	assert.False(t, span.FinishTime.Before(span.StartTime))
+}
@@ -1,7 +1,9 @@
 package mocktracer
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
@@ -12,6 +14,13 @@ import (
 const mockTextMapIdsPrefix = "mockpfx-ids-"
 const mockTextMapBaggagePrefix = "mockpfx-baggage-"
 
+// mockBinaryVersion is the first byte of every Binary-encoded MockSpanContext.
+const mockBinaryVersion byte = 1
+
+// mockBinaryMaxLen bounds the length of any single length-prefixed field so
+// that corrupted input can't trigger huge allocations.
+const mockBinaryMaxLen = 1 << 20
+
 var emptyContext = MockSpanContext{}
 
 // Injector is responsible for injecting SpanContext instances in a manner suitable
@@ -118,3 +127,129 @@ func (t *TextMapPropagator) Extract(carrier interface{}) (MockSpanContext, error
 	}
 	return rval, nil
 }
+
+// BinaryPropagator implements Injector/Extractor for the Binary format.
+//
+// The wire layout (all integers big-endian) is:
+//
+//     version     uint8   (currently 1)
+//     traceID     int64
+//     spanID      int64
+//     sampled     uint8   (0 or 1)
+//     numBaggage  uint32
+//     numBaggage times:
+//         keyLen  uint32
+//         key     [keyLen]byte
+//         valLen  uint32
+//         val     [valLen]byte
+type BinaryPropagator struct{}
+
+// Inject implements the Injector interface
+func (b *BinaryPropagator) Inject(spanContext MockSpanContext, carrier interface{}) error {
+	writer, ok := carrier.(io.Writer)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	size := 1 + 8 + 8 + 1 + 4
+	for k, v := range spanContext.Baggage {
+		size += 4 + len(k) + 4 + len(v)
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, mockBinaryVersion)
+	buf = appendUint64(buf, uint64(spanContext.TraceID))
+	buf = appendUint64(buf, uint64(spanContext.SpanID))
+	if spanContext.Sampled {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = appendUint32(buf, uint32(len(spanContext.Baggage)))
+	for k, v := range spanContext.Baggage {
+		buf = appendUint32(buf, uint32(len(k)))
+		buf = append(buf, k...)
+		buf = appendUint32(buf, uint32(len(v)))
+		buf = append(buf, v...)
+	}
+	_, err := writer.Write(buf)
+	return err
+}
+
+// Extract implements the Extractor interface
+func (b *BinaryPropagator) Extract(carrier interface{}) (MockSpanContext, error) {
+	reader, ok := carrier.(io.Reader)
+	if !ok {
+		return emptyContext, opentracing.ErrInvalidCarrier
+	}
+	var header [1 + 8 + 8 + 1 + 4]byte
+	if n, err := io.ReadFull(reader, header[:]); err != nil {
+		if n == 0 && err == io.EOF {
+			return emptyContext, opentracing.ErrSpanContextNotFound
+		}
+		return emptyContext, opentracing.ErrSpanContextCorrupted
+	}
+	if header[0] != mockBinaryVersion {
+		return emptyContext, opentracing.ErrSpanContextCorrupted
+	}
+	rval := MockSpanContext{
+		TraceID: int(binary.BigEndian.Uint64(header[1:9])),
+		SpanID:  int(binary.BigEndian.Uint64(header[9:17])),
+	}
+	switch header[17] {
+	case 0:
+		rval.Sampled = false
+	case 1:
+		rval.Sampled = true
+	default:
+		return emptyContext, opentracing.ErrSpanContextCorrupted
+	}
+	numBaggage := binary.BigEndian.Uint32(header[18:22])
+	if numBaggage > mockBinaryMaxLen {
+		return emptyContext, opentracing.ErrSpanContextCorrupted
+	}
+	if numBaggage > 0 {
+		rval.Baggage = make(map[string]string)
+	}
+	for i := uint32(0); i < numBaggage; i++ {
+		key, err := readBinaryString(reader)
+		if err != nil {
+			return emptyContext, err
+		}
+		val, err := readBinaryString(reader)
+		if err != nil {
+			return emptyContext, err
+		}
+		rval.Baggage[key] = val
+	}
+	if rval.TraceID == 0 || rval.SpanID == 0 {
+		return emptyContext, opentracing.ErrSpanContextNotFound
+	}
+	return rval, nil
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func readBinaryString(reader io.Reader) (string, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(reader, lenBuf[:]); err != nil {
+		return "", opentracing.ErrSpanContextCorrupted
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > mockBinaryMaxLen {
+		return "", opentracing.ErrSpanContextCorrupted
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", opentracing.ErrSpanContextCorrupted
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,241 @@
+package mocktracer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// SnapshotEncoder serializes traces into a deterministic text format that is
+// suitable for comparison with golden files:
+//
+//     trace 1
+//       span 1 "GET /x" start=+0s duration=12ms
+//         tag http.status_code=200
+//         span 2 "SELECT" start=+1ms duration=3ms
+//           child_of span 1
+//           log +1ms event=error
+//
+// Trace and span IDs are replaced by ordinal numbers in order of appearance,
+// and timestamps by offsets from the start of the trace. Tags and baggage
+// are sorted by key.
+type SnapshotEncoder struct {
+	// OmitTimings leaves out start times, durations and log offsets, for
+	// tests that do not control the clock. Traces and siblings are then
+	// ordered by operation name rather than by StartTime, and by their
+	// tags, logs and descendants when names are equal.
+	OmitTimings bool
+}
+
+// Encode serializes `traces`, typically obtained from MockTracer.Traces().
+func (e SnapshotEncoder) Encode(traces []*MockTrace) []byte {
+	s := &snapshot{enc: e, spanIDs: make(map[int]int), keys: make(map[*MockSpanNode]string)}
+	traces = s.sortedTraces(traces)
+	// Number all spans first, so that references to later spans resolve.
+	for _, tr := range traces {
+		for _, n := range s.sorted(tr.Roots) {
+			s.number(n)
+		}
+		for _, n := range s.sorted(tr.Orphans) {
+			s.number(n)
+		}
+	}
+	for i, tr := range traces {
+		fmt.Fprintf(&s.buf, "trace %d\n", i+1)
+		start := traceStart(tr)
+		for _, n := range s.sorted(tr.Roots) {
+			s.span(n, start, 1)
+		}
+		for _, n := range s.sorted(tr.Orphans) {
+			s.span(n, start, 1)
+		}
+	}
+	return s.buf.Bytes()
+}
+
+type snapshot struct {
+	enc     SnapshotEncoder
+	buf     bytes.Buffer
+	spanIDs map[int]int
+	keys    map[*MockSpanNode]string // see key()
+}
+
+func (s *snapshot) sortedTraces(traces []*MockTrace) []*MockTrace {
+	if !s.enc.OmitTimings {
+		return traces
+	}
+	keys := make(map[*MockTrace]string, len(traces))
+	for _, tr := range traces {
+		var b bytes.Buffer
+		for _, n := range s.sorted(tr.Roots) {
+			b.WriteString(s.key(n))
+		}
+		b.WriteString("|")
+		for _, n := range s.sorted(tr.Orphans) {
+			b.WriteString(s.key(n))
+		}
+		keys[tr] = b.String()
+	}
+	sorted := append([]*MockTrace(nil), traces...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return keys[sorted[i]] < keys[sorted[j]]
+	})
+	return sorted
+}
+
+func (s *snapshot) sorted(nodes []*MockSpanNode) []*MockSpanNode {
+	if !s.enc.OmitTimings {
+		return nodes
+	}
+	sorted := append([]*MockSpanNode(nil), nodes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Span.OperationName, sorted[j].Span.OperationName
+		if a != b {
+			return a < b
+		}
+		return s.key(sorted[i]) < s.key(sorted[j])
+	})
+	return sorted
+}
+
+// key renders the subtree of `n` without IDs and timings, so that spans
+// can be ordered independently of the clock.
+func (s *snapshot) key(n *MockSpanNode) string {
+	if k, ok := s.keys[n]; ok {
+		return k
+	}
+	sp := n.Span
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%q sampled=%t", sp.OperationName, sp.SpanContext.Sampled)
+	for _, ref := range sp.References() {
+		fmt.Fprintf(&b, " ref=%d%s", ref.Type, formatSorted(ref.Attributes, ","))
+	}
+	b.WriteString(formatSorted(sp.Tags(), " tag "))
+	b.WriteString(formatSorted(baggage(sp), " baggage "))
+	for _, rec := range sp.Logs() {
+		b.WriteString(" log")
+		for _, f := range rec.Fields {
+			fmt.Fprintf(&b, ",%s=%s", f.Key, f.ValueString)
+		}
+	}
+	b.WriteString(" (")
+	for _, c := range s.sorted(n.Children) {
+		b.WriteString(s.key(c))
+	}
+	b.WriteString(")")
+	s.keys[n] = b.String()
+	return s.keys[n]
+}
+
+func (s *snapshot) number(n *MockSpanNode) {
+	s.spanIDs[n.Span.SpanContext.SpanID] = len(s.spanIDs) + 1
+	for _, c := range s.sorted(n.Children) {
+		s.number(c)
+	}
+}
+
+func traceStart(tr *MockTrace) time.Time {
+	var start time.Time
+	for _, nodes := range [][]*MockSpanNode{tr.Roots, tr.Orphans} {
+		for _, n := range nodes {
+			if start.IsZero() || n.Span.StartTime.Before(start) {
+				start = n.Span.StartTime
+			}
+		}
+	}
+	return start
+}
+
+func (s *snapshot) line(depth int, format string, args ...interface{}) {
+	for i := 0; i < depth; i++ {
+		s.buf.WriteString("  ")
+	}
+	fmt.Fprintf(&s.buf, format, args...)
+	s.buf.WriteByte('\n')
+}
+
+func (s *snapshot) ref(spanID int) string {
+	if id, ok := s.spanIDs[spanID]; ok {
+		return fmt.Sprintf("span %d", id)
+	}
+	return "unfinished span"
+}
+
+func (s *snapshot) span(n *MockSpanNode, traceStart time.Time, depth int) {
+	sp := n.Span
+	if s.enc.OmitTimings {
+		s.line(depth, "span %d %q", s.spanIDs[sp.SpanContext.SpanID], sp.OperationName)
+	} else {
+		s.line(depth, "span %d %q start=+%v duration=%v", s.spanIDs[sp.SpanContext.SpanID], sp.OperationName,
+			sp.StartTime.Sub(traceStart), sp.FinishTime.Sub(sp.StartTime))
+	}
+	if !sp.SpanContext.Sampled {
+		s.line(depth+1, "unsampled")
+	}
+	for _, ref := range sp.References() {
+		name := "child_of"
+		switch ref.Type {
+		case opentracing.FollowsFromRef:
+			name = "follows_from"
+		case opentracing.LinkRef:
+			name = "link"
+		}
+		target := "foreign span"
+		if sc, ok := ref.ReferencedContext.(MockSpanContext); ok {
+			target = s.ref(sc.SpanID)
+		}
+		s.line(depth+1, "%s %s%s", name, target, formatSorted(ref.Attributes, " "))
+	}
+	tags := sp.Tags()
+	for _, k := range sortedKeys(tags) {
+		s.line(depth+1, "tag %s=%v", k, tags[k])
+	}
+	bag := baggage(sp)
+	for _, k := range sortedKeys(bag) {
+		s.line(depth+1, "baggage %s=%v", k, bag[k])
+	}
+	for _, rec := range sp.Logs() {
+		var fields bytes.Buffer
+		for _, f := range rec.Fields {
+			fmt.Fprintf(&fields, " %s=%s", f.Key, f.ValueString)
+		}
+		if s.enc.OmitTimings {
+			s.line(depth+1, "log%s", fields.String())
+		} else {
+			s.line(depth+1, "log +%v%s", rec.Timestamp.Sub(sp.StartTime), fields.String())
+		}
+	}
+	for _, c := range s.sorted(n.Children) {
+		s.span(c, traceStart, depth+1)
+	}
+}
+
+func baggage(sp *MockSpan) map[string]interface{} {
+	m := make(map[string]interface{}, len(sp.SpanContext.Baggage))
+	for k, v := range sp.SpanContext.Baggage {
+		m[k] = v
+	}
+	return m
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatSorted renders the map as sorted key=value pairs, each preceded by
+// `sep`.
+func formatSorted(m map[string]interface{}, sep string) string {
+	var buf bytes.Buffer
+	for _, k := range sortedKeys(m) {
+		fmt.Fprintf(&buf, "%s%s=%v", sep, k, m[k])
+	}
+	return buf.String()
+}
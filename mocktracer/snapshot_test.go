@@ -0,0 +1,123 @@
+package mocktracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+func TestSnapshotEncoder(t *testing.T) {
+	tracer := New()
+	start := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	finish := func(sp opentracing.Span, ms int) {
+		sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(ms)})
+	}
+
+	producer := tracer.StartSpan("produce", opentracing.StartTime(at(-5)))
+	finish(producer, -4)
+	root := tracer.StartSpan("GET /x", opentracing.StartTime(at(0)),
+		opentracing.Tags{"span.kind": "server", "http.status_code": 200})
+	root.SetBaggageItem("user", "alice")
+	render := tracer.StartSpan("render", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(6)))
+	query := tracer.StartSpan("SELECT", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(1)),
+		opentracing.Link(producer.Context(), opentracing.Tags{"reason": "batch"}))
+	query.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: at(4),
+		LogRecords: []opentracing.LogRecord{{Timestamp: at(2), Fields: []log.Field{log.String("event", "error")}}},
+	})
+	unfinished := tracer.StartSpan("unfinished", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(7)))
+	orphan := tracer.StartSpan("orphan", opentracing.ChildOf(unfinished.Context()), opentracing.StartTime(at(8)))
+	finish(orphan, 9)
+	finish(render, 10)
+	finish(root, 12)
+
+	assert.Equal(t, `trace 1
+  span 1 "produce" start=+0s duration=1ms
+trace 2
+  span 2 "GET /x" start=+0s duration=12ms
+    tag http.status_code=200
+    tag span.kind=server
+    baggage user=alice
+    span 3 "SELECT" start=+1ms duration=3ms
+      child_of span 2
+      link span 1 reason=batch
+      baggage user=alice
+      log +1ms event=error
+    span 4 "render" start=+6ms duration=4ms
+      child_of span 2
+      baggage user=alice
+  span 5 "orphan" start=+8ms duration=1ms
+    child_of unfinished span
+    baggage user=alice
+`, string(SnapshotEncoder{}.Encode(tracer.Traces())))
+
+	assert.Equal(t, `trace 1
+  span 1 "GET /x"
+    tag http.status_code=200
+    tag span.kind=server
+    baggage user=alice
+    span 2 "SELECT"
+      child_of span 1
+      link span 5 reason=batch
+      baggage user=alice
+      log event=error
+    span 3 "render"
+      child_of span 1
+      baggage user=alice
+  span 4 "orphan"
+    child_of unfinished span
+    baggage user=alice
+trace 2
+  span 5 "produce"
+`, string(SnapshotEncoder{OmitTimings: true}.Encode(tracer.Traces())))
+}
+
+func TestSnapshotEncoder_OmitTimingsIgnoresStartOrder(t *testing.T) {
+	start := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	record := func(reversed bool) []byte {
+		tracer := New()
+		at := func(i int) opentracing.StartTime {
+			if reversed {
+				i = 100 - i
+			}
+			return opentracing.StartTime(start.Add(time.Duration(i) * time.Millisecond))
+		}
+		for _, job := range []int{1, 2} {
+			root := tracer.StartSpan("job", at(10*job), opentracing.Tag{Key: "job", Value: job})
+			for i, item := range []string{"a", "b"} {
+				child := tracer.StartSpan("item", at(10*job+i+1), opentracing.ChildOf(root.Context()))
+				child.LogFields(log.String("item", item))
+				child.Finish()
+			}
+			root.Finish()
+		}
+		return SnapshotEncoder{OmitTimings: true}.Encode(tracer.Traces())
+	}
+
+	expected := `trace 1
+  span 1 "job"
+    tag job=1
+    span 2 "item"
+      child_of span 1
+      log item=a
+    span 3 "item"
+      child_of span 1
+      log item=b
+trace 2
+  span 4 "job"
+    tag job=2
+    span 5 "item"
+      child_of span 4
+      log item=a
+    span 6 "item"
+      child_of span 4
+      log item=b
+`
+	assert.Equal(t, expected, string(record(false)))
+	assert.Equal(t, expected, string(record(true)))
+}
@@ -0,0 +1,158 @@
+package mocktracer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MockTrace is the tree of finished spans sharing a TraceID.
+type MockTrace struct {
+	TraceID int
+
+	// Roots are the spans of the trace without a parent, ordered by
+	// StartTime. Usually there is exactly one.
+	Roots []*MockSpanNode
+
+	// Orphans are the spans of the trace whose parent never finished (or
+	// was started by another process), ordered by StartTime, with their
+	// descendants.
+	Orphans []*MockSpanNode
+}
+
+// MockSpanNode is a finished span together with its finished children,
+// ordered by StartTime.
+type MockSpanNode struct {
+	Span     *MockSpan
+	Children []*MockSpanNode
+}
+
+// Traces groups the spans that have been Finish()'ed since the MockTracer
+// was constructed or since the last call to its Reset() method into trees,
+// one per trace. Traces are ordered by the StartTime of their earliest span.
+func (t *MockTracer) Traces() []*MockTrace {
+	return buildTraces(t.FinishedSpans())
+}
+
+func buildTraces(spans []*MockSpan) []*MockTrace {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+	type spanKey struct{ traceID, spanID int }
+	nodes := make(map[spanKey]*MockSpanNode, len(spans))
+	for _, sp := range spans {
+		nodes[spanKey{sp.SpanContext.TraceID, sp.SpanContext.SpanID}] = &MockSpanNode{Span: sp}
+	}
+	var traces []*MockTrace
+	byID := make(map[int]*MockTrace)
+	for _, sp := range spans {
+		node := nodes[spanKey{sp.SpanContext.TraceID, sp.SpanContext.SpanID}]
+		trace := byID[sp.SpanContext.TraceID]
+		if trace == nil {
+			trace = &MockTrace{TraceID: sp.SpanContext.TraceID}
+			byID[trace.TraceID] = trace
+			traces = append(traces, trace)
+		}
+		if sp.ParentID == 0 {
+			trace.Roots = append(trace.Roots, node)
+		} else if parent, ok := nodes[spanKey{sp.SpanContext.TraceID, sp.ParentID}]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			trace.Orphans = append(trace.Orphans, node)
+		}
+	}
+	return traces
+}
+
+// Spans returns all spans of the trace in depth-first order, roots first.
+func (tr *MockTrace) Spans() []*MockSpan {
+	var spans []*MockSpan
+	var walk func(n *MockSpanNode)
+	walk = func(n *MockSpanNode) {
+		spans = append(spans, n.Span)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, n := range tr.Roots {
+		walk(n)
+	}
+	for _, n := range tr.Orphans {
+		walk(n)
+	}
+	return spans
+}
+
+// TreeStyle is the set of strings used to draw the branches of a rendered
+// trace.
+type TreeStyle struct {
+	Branch     string // prefix of a node followed by siblings
+	LastBranch string // prefix of the last node among its siblings
+	Vertical   string // continues the branch of a node followed by siblings
+	Space      string // indents below the last node among its siblings
+}
+
+var (
+	// UnicodeTree draws branches with box-drawing characters.
+	UnicodeTree = TreeStyle{Branch: "├── ", LastBranch: "└── ", Vertical: "│   ", Space: "    "}
+
+	// ASCIITree draws branches with ASCII characters only.
+	ASCIITree = TreeStyle{Branch: "|-- ", LastBranch: "`-- ", Vertical: "|   ", Space: "    "}
+)
+
+// String renders the trace with UnicodeTree.
+func (tr *MockTrace) String() string {
+	return tr.Render(UnicodeTree)
+}
+
+// Render renders the trace as a tree, e.g. for test failure output. Each
+// span is shown with its operation name, duration and tags, followed by its
+// log records with their offset from the span's StartTime:
+//
+//     trace 43
+//     └── GET /x 12ms [http.status_code=200 span.kind=server]
+//         ├── SELECT 3ms
+//         │       +1ms event=error
+//         └── render 5ms
+//
+// Orphans are listed after the roots, together with the missing parent ID.
+func (tr *MockTrace) Render(style TreeStyle) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace %d\n", tr.TraceID)
+	nodes := append(append([]*MockSpanNode(nil), tr.Roots...), tr.Orphans...)
+	for i, n := range nodes {
+		renderNode(&b, style, n, "", i == len(nodes)-1, i >= len(tr.Roots))
+	}
+	return b.String()
+}
+
+func renderNode(b *strings.Builder, style TreeStyle, n *MockSpanNode, indent string, last, orphan bool) {
+	branch, childIndent := style.Branch, indent+style.Vertical
+	if last {
+		branch, childIndent = style.LastBranch, indent+style.Space
+	}
+	sp := n.Span
+	b.WriteString(indent + branch + sp.OperationName + " " + sp.FinishTime.Sub(sp.StartTime).String())
+	if tags := sp.Tags(); len(tags) > 0 {
+		b.WriteString(" [" + formatSorted(tags, " ")[1:] + "]")
+	}
+	if orphan {
+		fmt.Fprintf(b, " (orphan: parent %d did not finish)", sp.ParentID)
+	}
+	b.WriteString("\n")
+
+	logIndent := childIndent + style.Space
+	if len(n.Children) > 0 {
+		logIndent = childIndent + style.Vertical
+	}
+	for _, rec := range sp.Logs() {
+		b.WriteString(logIndent + "+" + rec.Timestamp.Sub(sp.StartTime).String())
+		for _, f := range rec.Fields {
+			fmt.Fprintf(b, " %s=%s", f.Key, f.ValueString)
+		}
+		b.WriteString("\n")
+	}
+	for i, c := range n.Children {
+		renderNode(b, style, c, childIndent, i == len(n.Children)-1, false)
+	}
+}
@@ -0,0 +1,71 @@
+package mocktracer
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+func TestMockTracer_Traces(t *testing.T) {
+	tracer := New()
+	start := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	finish := func(sp opentracing.Span, ms int) {
+		sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(ms)})
+	}
+
+	root := tracer.StartSpan("GET /x", opentracing.StartTime(at(0)), opentracing.Tag{Key: "http.status_code", Value: 200})
+	render := tracer.StartSpan("render", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(6)))
+	query := tracer.StartSpan("SELECT", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(1)))
+	query.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: at(4),
+		LogRecords: []opentracing.LogRecord{{Timestamp: at(2), Fields: []log.Field{log.String("event", "error")}}},
+	})
+	unfinished := tracer.StartSpan("unfinished", opentracing.ChildOf(root.Context()), opentracing.StartTime(at(7)))
+	orphan := tracer.StartSpan("orphan", opentracing.ChildOf(unfinished.Context()), opentracing.StartTime(at(8)))
+	finish(orphan, 9)
+	finish(render, 10)
+	finish(root, 12)
+	other := tracer.StartSpan("other", opentracing.StartTime(start.Add(-time.Second)))
+	finish(other, 0)
+
+	traces := tracer.Traces()
+	require.Len(t, traces, 2)
+	assert.Equal(t, other.Context().(MockSpanContext).TraceID, traces[0].TraceID)
+	tr := traces[1]
+	require.Len(t, tr.Roots, 1)
+	assert.Equal(t, root, tr.Roots[0].Span)
+	require.Len(t, tr.Roots[0].Children, 2)
+	assert.Equal(t, query, tr.Roots[0].Children[0].Span, "children must be ordered by StartTime")
+	assert.Equal(t, render, tr.Roots[0].Children[1].Span)
+	require.Len(t, tr.Orphans, 1)
+	assert.Equal(t, orphan, tr.Orphans[0].Span)
+	assert.Equal(t, []*MockSpan{
+		root.(*MockSpan), query.(*MockSpan), render.(*MockSpan), orphan.(*MockSpan),
+	}, tr.Spans())
+
+	unfinishedID := unfinished.Context().(MockSpanContext).SpanID
+	assert.Equal(t, `trace `+strconv.Itoa(tr.TraceID)+`
+├── GET /x 12ms [http.status_code=200]
+│   ├── SELECT 3ms
+│   │       +1ms event=error
+│   └── render 4ms
+└── orphan 1ms (orphan: parent `+strconv.Itoa(unfinishedID)+` did not finish)
+`, tr.String())
+	assert.Equal(t, `trace `+strconv.Itoa(tr.TraceID)+`
+|-- GET /x 12ms [http.status_code=200]
+|   |-- SELECT 3ms
+|   |       +1ms event=error
+|   `+"`"+`-- render 4ms
+`+"`"+`-- orphan 1ms (orphan: parent `+strconv.Itoa(unfinishedID)+` did not finish)
+`, tr.Render(ASCIITree))
+
+	tracer.Reset()
+	assert.Empty(t, tracer.Traces())
+}
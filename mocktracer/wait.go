@@ -0,0 +1,163 @@
+package mocktracer
+
+import (
+	"context"
+	"sync"
+)
+
+// finishSubscription delivers finished spans to a SubscribeFinishedSpans
+// channel until its context is done. Spans are queued so that Finish()
+// never waits for the subscriber.
+type finishSubscription struct {
+	ch   chan *MockSpan
+	wake chan struct{}
+
+	sync.Mutex
+	queue []*MockSpan
+}
+
+func (s *finishSubscription) push(span *MockSpan) {
+	s.Lock()
+	s.queue = append(s.queue, span)
+	s.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the oldest queued span, or nil if the queue is empty.
+func (s *finishSubscription) pop() *MockSpan {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.queue) == 0 {
+		return nil
+	}
+	span := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return span
+}
+
+// WaitForSpans blocks until at least `n` spans have finished, then returns
+// all finished spans as FinishedSpans() does. If `ctx` is done first, it
+// returns the spans finished so far and ctx.Err().
+func (t *MockTracer) WaitForSpans(ctx context.Context, n int) ([]*MockSpan, error) {
+	for {
+		t.Lock()
+		if len(t.finishedSpans) >= n {
+			spans := make([]*MockSpan, len(t.finishedSpans))
+			copy(spans, t.finishedSpans)
+			t.Unlock()
+			return spans, nil
+		}
+		signal := t.finishSignal()
+		t.Unlock()
+
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			return t.FinishedSpans(), ctx.Err()
+		}
+	}
+}
+
+// WaitForSpan blocks until a finished span satisfies `predicate`, and
+// returns the first such span. If `ctx` is done first, it returns nil and
+// ctx.Err().
+func (t *MockTracer) WaitForSpan(ctx context.Context, predicate func(*MockSpan) bool) (*MockSpan, error) {
+	checked, resets := 0, 0
+	for {
+		t.Lock()
+		// Spans before `checked` were already rejected, unless Reset() was
+		// called in the meantime.
+		if resets != t.resets {
+			checked, resets = 0, t.resets
+		}
+		pending := append([]*MockSpan(nil), t.finishedSpans[checked:]...)
+		signal := t.finishSignal()
+		t.Unlock()
+
+		// The predicate runs without the lock, so it may inspect the span.
+		for _, sp := range pending {
+			if predicate(sp) {
+				return sp, nil
+			}
+		}
+		checked += len(pending)
+
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// SubscribeFinishedSpans returns a channel that receives every span that
+// finishes from now on, in the order FinishedSpans() returns them, until
+// `ctx` is done, at which point the channel is closed.
+//
+// Finish() never blocks on the subscriber: spans are queued until they are
+// received, and those still queued when `ctx` is done are dropped.
+func (t *MockTracer) SubscribeFinishedSpans(ctx context.Context) <-chan *MockSpan {
+	sub := &finishSubscription{
+		ch:   make(chan *MockSpan),
+		wake: make(chan struct{}, 1),
+	}
+	t.Lock()
+	t.subscriptions = append(t.subscriptions, sub)
+	t.Unlock()
+
+	go func() {
+		defer func() {
+			t.Lock()
+			for i, s := range t.subscriptions {
+				if s == sub {
+					t.subscriptions = append(t.subscriptions[:i], t.subscriptions[i+1:]...)
+					break
+				}
+			}
+			t.Unlock()
+			close(sub.ch)
+		}()
+		for {
+			span := sub.pop()
+			if span == nil {
+				select {
+				case <-sub.wake:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case sub.ch <- span:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sub.ch
+}
+
+// finishSignal returns a channel that is closed when the next span
+// finishes. The caller MUST hold t.Lock.
+func (t *MockTracer) finishSignal() <-chan struct{} {
+	if t.finished == nil {
+		t.finished = make(chan struct{})
+	}
+	return t.finished
+}
+
+// notifyFinished wakes up waiters and queues a span that just finished for
+// subscribers. The caller MUST hold t.Lock.
+func (t *MockTracer) notifyFinished(span *MockSpan) {
+	if t.finished != nil {
+		close(t.finished)
+		t.finished = nil
+	}
+	for _, sub := range t.subscriptions {
+		sub.push(span)
+	}
+}
@@ -0,0 +1,112 @@
+package mocktracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func finishInBackground(tracer *MockTracer, names ...string) {
+	go func() {
+		for _, name := range names {
+			tracer.StartSpan(name).Finish()
+		}
+	}()
+}
+
+func TestMockTracer_WaitForSpans(t *testing.T) {
+	tracer := New()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	finishInBackground(tracer, "a", "b", "c")
+	spans, err := tracer.WaitForSpans(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, spans, 3)
+	assert.Equal(t, "c", spans[2].OperationName)
+
+	spans, err = tracer.WaitForSpans(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, spans, 3)
+
+	short, cancelShort := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShort()
+	spans, err = tracer.WaitForSpans(short, 4)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Len(t, spans, 3)
+}
+
+func TestMockTracer_WaitForSpan(t *testing.T) {
+	tracer := New()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tracer.StartSpan("before").Finish()
+	finishInBackground(tracer, "a", "target", "b")
+	sp, err := tracer.WaitForSpan(ctx, func(sp *MockSpan) bool { return sp.OperationName == "target" })
+	require.NoError(t, err)
+	assert.Equal(t, "target", sp.OperationName)
+
+	sp, err = tracer.WaitForSpan(ctx, func(sp *MockSpan) bool { return sp.OperationName == "before" })
+	require.NoError(t, err)
+	assert.Equal(t, "before", sp.OperationName)
+
+	canceled, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	sp, err = tracer.WaitForSpan(canceled, func(*MockSpan) bool { return false })
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, sp)
+}
+
+func TestMockTracer_WaitForSpanAfterReset(t *testing.T) {
+	tracer := New()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tracer.StartSpan("a").Finish()
+	tracer.StartSpan("b").Finish()
+	found := make(chan *MockSpan)
+	go func() {
+		sp, _ := tracer.WaitForSpan(ctx, func(sp *MockSpan) bool { return sp.OperationName == "c" })
+		found <- sp
+	}()
+	tracer.Reset()
+	tracer.StartSpan("c").Finish()
+	sp := <-found
+	require.NotNil(t, sp)
+	assert.Equal(t, "c", sp.OperationName)
+}
+
+func TestMockTracer_SubscribeFinishedSpans(t *testing.T) {
+	tracer := New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tracer.StartSpan("before").Finish()
+	events := tracer.SubscribeFinishedSpans(ctx)
+	finishInBackground(tracer, "a", "b")
+	assert.Equal(t, "a", (<-events).OperationName)
+	assert.Equal(t, "b", (<-events).OperationName)
+
+	// An idle subscriber must not block Finish().
+	tracer.StartSpan("c").Finish()
+	tracer.StartSpan("d").Finish()
+	assert.Equal(t, "c", (<-events).OperationName)
+
+	cancel()
+	for range events {
+		// Drain until the channel is closed.
+	}
+	done := make(chan struct{})
+	go func() {
+		tracer.StartSpan("after").Finish()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Finish() blocked after the subscription was canceled")
+	}
+}
@@ -0,0 +1,318 @@
+package nethttp
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+type clientOptions struct {
+	opNameFunc    func(r *http.Request) string
+	spanObserver  func(span opentracing.Span, r *http.Request)
+	urlTagFunc    func(u *url.URL) string
+	componentName string
+	clientTrace   bool
+}
+
+// ClientOption controls the behavior of the Transport.
+type ClientOption func(*clientOptions)
+
+// ClientOperationNameFunc returns a ClientOption that uses given function f
+// to generate operation name for each client-side span.
+func ClientOperationNameFunc(f func(r *http.Request) string) ClientOption {
+	return func(options *clientOptions) {
+		options.opNameFunc = f
+	}
+}
+
+// ClientComponentName returns a ClientOption that sets the component name
+// for the client-side span.
+func ClientComponentName(componentName string) ClientOption {
+	return func(options *clientOptions) {
+		options.componentName = componentName
+	}
+}
+
+// ClientSpanObserver returns a ClientOption that observes the span
+// for the client-side span, e.g. to set additional tags.
+func ClientSpanObserver(f func(span opentracing.Span, r *http.Request)) ClientOption {
+	return func(options *clientOptions) {
+		options.spanObserver = f
+	}
+}
+
+// ClientURLTagFunc returns a ClientOption that uses given function f to set
+// the span's http.url tag, e.g. to redact query parameters.
+func ClientURLTagFunc(f func(u *url.URL) string) ClientOption {
+	return func(options *clientOptions) {
+		options.urlTagFunc = f
+	}
+}
+
+// ClientTrace returns a ClientOption that turns on or off the
+// httptrace.ClientTrace integration, which logs DNS, connect, TLS and
+// first-byte events to the client-side span.
+func ClientTrace(enabled bool) ClientOption {
+	return func(options *clientOptions) {
+		options.clientTrace = enabled
+	}
+}
+
+// Transport wraps a RoundTripper. For every request it starts a client-side
+// span as a child of the span found in the request's context (or a root
+// span if there is none), and injects it into the outgoing request headers.
+//
+// The span is finished when the response body is closed, so that it covers
+// the time spent reading the response. Callers must close the body, as
+// net/http already requires.
+//
+// Example:
+//
+//     client := &http.Client{Transport: nethttp.NewTransport(tracer, nil)}
+//     req = req.WithContext(opentracing.ContextWithSpan(req.Context(), parentSpan))
+//     res, err := client.Do(req)
+type Transport struct {
+	tracer opentracing.Tracer
+	opts   clientOptions
+
+	// The actual RoundTripper to use for the request. A nil
+	// RoundTripper defaults to http.DefaultTransport.
+	http.RoundTripper
+}
+
+// NewTransport returns a Transport that traces requests sent through rt.
+func NewTransport(tr opentracing.Tracer, rt http.RoundTripper, options ...ClientOption) *Transport {
+	opts := clientOptions{
+		opNameFunc: func(r *http.Request) string {
+			return "HTTP " + r.Method
+		},
+		spanObserver: func(span opentracing.Span, r *http.Request) {},
+		urlTagFunc: func(u *url.URL) string {
+			return u.String()
+		},
+		componentName: defaultComponentName,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Transport{tracer: tr, opts: opts, RoundTripper: rt}
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.RoundTripper
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	var parentCtx opentracing.SpanContext
+	if parent := opentracing.SpanFromContext(req.Context()); parent != nil {
+		parentCtx = parent.Context()
+	}
+	sp := t.tracer.StartSpan(t.opts.opNameFunc(req),
+		opentracing.ChildOf(parentCtx), ext.SpanKindRPCClient)
+	ext.Component.Set(sp, t.opts.componentName)
+	ext.HTTPMethod.Set(sp, req.Method)
+	ext.HTTPUrl.Set(sp, t.opts.urlTagFunc(req.URL))
+	setPeerTags(sp, req.URL)
+	t.opts.spanObserver(sp, req)
+
+	cs := &clientSpan{sp: sp}
+	ctx := opentracing.ContextWithSpan(req.Context(), sp)
+	if t.opts.clientTrace {
+		ctx = httptrace.WithClientTrace(ctx, newClientTrace(cs))
+	}
+	// RoundTrippers must not modify the caller's request.
+	outReq := req.Clone(ctx)
+	carrier := opentracing.HTTPHeadersCarrier(outReq.Header)
+	if err := t.tracer.Inject(sp.Context(), opentracing.HTTPHeaders, carrier); err != nil {
+		sp.LogFields(log.String("event", "inject failed"), log.Error(err))
+	}
+
+	resp, err := rt.RoundTrip(outReq)
+	if err != nil {
+		ext.LogError(sp, err)
+		cs.finish()
+		return resp, err
+	}
+	ext.HTTPStatusCode.Set(sp, uint16(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		ext.Error.Set(sp, true)
+	}
+	resp.Body = newClosingBody(resp.Body, cs)
+	return resp, nil
+}
+
+func setPeerTags(sp opentracing.Span, u *url.URL) {
+	host, port := u.Hostname(), u.Port()
+	if host != "" {
+		if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+			ext.PeerHostIPv4.SetString(sp, host)
+		} else if ip != nil {
+			ext.PeerHostIPv6.Set(sp, host)
+		} else {
+			ext.PeerHostname.Set(sp, host)
+		}
+	}
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+		ext.PeerPort.Set(sp, uint16(p))
+	}
+}
+
+// clientSpan is the client-side span of a request. httptrace hooks may
+// still fire after the span finished, e.g. when a connection the request
+// started dialing is put in the idle pool, and the body may be read after it
+// was closed, so they log through logFields and logError, which do nothing
+// once finish has been called.
+type clientSpan struct {
+	sp       opentracing.Span
+	mu       sync.Mutex
+	finished bool
+}
+
+func (s *clientSpan) logFields(fields ...log.Field) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		s.sp.LogFields(fields...)
+	}
+}
+
+func (s *clientSpan) logError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		ext.LogError(s.sp, err)
+	}
+}
+
+func (s *clientSpan) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		s.finished = true
+		s.sp.Finish()
+	}
+}
+
+// closingBody finishes the span once the response body is closed. Read
+// errors other than io.EOF are logged to the span.
+type closingBody struct {
+	io.ReadCloser
+	cs *clientSpan
+}
+
+func newClosingBody(body io.ReadCloser, cs *clientSpan) io.ReadCloser {
+	cb := &closingBody{ReadCloser: body, cs: cs}
+	if w, ok := body.(io.Writer); ok {
+		// Responses to protocol upgrades have writable bodies.
+		return struct {
+			*closingBody
+			io.Writer
+		}{cb, w}
+	}
+	return cb
+}
+
+func (b *closingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.cs.logError(err)
+	}
+	return n, err
+}
+
+func (b *closingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cs.finish()
+	return err
+}
+
+// newClientTrace returns an httptrace.ClientTrace that logs connection
+// lifecycle events to the span of cs.
+func newClientTrace(cs *clientSpan) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			cs.logFields(log.Event("GetConn"), log.String("hostPort", hostPort))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			cs.logFields(
+				log.Event("GotConn"),
+				log.Bool("reused", info.Reused),
+				log.Bool("wasIdle", info.WasIdle))
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			cs.logFields(log.Event("DNSStart"), log.String("host", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			fields := []log.Field{log.Event("DNSDone")}
+			for _, addr := range info.Addrs {
+				fields = append(fields, log.String("addr", addr.String()))
+			}
+			if info.Err != nil {
+				fields = append(fields, log.Error(info.Err))
+			}
+			cs.logFields(fields...)
+		},
+		ConnectStart: func(network, addr string) {
+			cs.logFields(
+				log.Event("ConnectStart"),
+				log.String("network", network),
+				log.String("addr", addr))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			fields := []log.Field{
+				log.Event("ConnectDone"),
+				log.String("network", network),
+				log.String("addr", addr),
+			}
+			if err != nil {
+				fields = append(fields, log.Error(err))
+			}
+			cs.logFields(fields...)
+		},
+		TLSHandshakeStart: func() {
+			cs.logFields(log.Event("TLSHandshakeStart"))
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			fields := []log.Field{
+				log.Event("TLSHandshakeDone"),
+				log.String("serverName", state.ServerName),
+			}
+			if err != nil {
+				fields = append(fields, log.Error(err))
+			}
+			cs.logFields(fields...)
+		},
+		WroteHeaders: func() {
+			cs.logFields(log.Event("WroteHeaders"))
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				cs.logFields(log.Event("WroteRequest"), log.Error(info.Err))
+			} else {
+				cs.logFields(log.Event("WroteRequest"))
+			}
+		},
+		GotFirstResponseByte: func() {
+			cs.logFields(log.Event("GotFirstResponseByte"))
+		},
+	}
+}
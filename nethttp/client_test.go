@@ -0,0 +1,158 @@
+package nethttp
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestTransport(t *testing.T) {
+	tr := mocktracer.New()
+	var serverCtx mocktracer.MockSpanContext
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, err := tr.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+		require.NoError(t, err)
+		serverCtx = sc.(mocktracer.MockSpanContext)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream failed"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	parent := tr.StartSpan("parent")
+	req, err := http.NewRequest("GET", srv.URL+"/path", nil)
+	require.NoError(t, err)
+	req = req.WithContext(opentracing.ContextWithSpan(req.Context(), parent))
+
+	client := &http.Client{Transport: NewTransport(tr, nil, ClientTrace(true))}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	assert.Empty(t, req.Header, "caller's request must not be modified")
+	assert.Empty(t, tr.FinishedSpans(), "span must stay open until the body is closed")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "upstream failed", string(body))
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, resp.Body.Close())
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	sp := spans[0]
+	parentCtx := parent.Context().(mocktracer.MockSpanContext)
+	assert.Equal(t, "HTTP GET", sp.OperationName)
+	assert.Equal(t, parentCtx.SpanID, sp.ParentID)
+	assert.Equal(t, sp.SpanContext.SpanID, serverCtx.SpanID)
+	assert.Equal(t, sp.SpanContext.TraceID, serverCtx.TraceID)
+
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		string(ext.SpanKind):       ext.SpanKindRPCClientEnum,
+		string(ext.Component):      defaultComponentName,
+		string(ext.HTTPMethod):     "GET",
+		string(ext.HTTPUrl):        srv.URL + "/path",
+		string(ext.PeerHostIPv4):   "127.0.0.1",
+		string(ext.PeerPort):       uint16(port),
+		string(ext.HTTPStatusCode): uint16(http.StatusBadGateway),
+		string(ext.Error):          true,
+	}, sp.Tags())
+
+	var events []string
+	for _, lr := range sp.Logs() {
+		events = append(events, lr.Fields[0].ValueString)
+	}
+	assert.Contains(t, events, "GetConn")
+	assert.Contains(t, events, "ConnectStart")
+	assert.Contains(t, events, "ConnectDone")
+	assert.Contains(t, events, "GotConn")
+	assert.Contains(t, events, "GotFirstResponseByte")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport_Error(t *testing.T) {
+	tr := mocktracer.New()
+	failure := errors.New("connection refused")
+	transport := NewTransport(tr, roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, failure
+	}),
+		ClientOperationNameFunc(func(r *http.Request) string { return "fetch" }),
+		ClientComponentName("my-client"),
+		ClientURLTagFunc(func(u *url.URL) string { return u.Path }),
+		ClientSpanObserver(func(sp opentracing.Span, r *http.Request) { sp.SetTag("observed", true) }),
+	)
+	req := httptest.NewRequest("GET", "https://example.com/x?secret=1", nil)
+	_, err := transport.RoundTrip(req)
+	assert.Equal(t, failure, err)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	sp := spans[0]
+	assert.Equal(t, "fetch", sp.OperationName)
+	assert.Zero(t, sp.ParentID)
+	assert.Equal(t, "my-client", sp.Tag(string(ext.Component)))
+	assert.Equal(t, "/x", sp.Tag(string(ext.HTTPUrl)))
+	assert.Equal(t, "example.com", sp.Tag(string(ext.PeerHostname)))
+	assert.Equal(t, uint16(443), sp.Tag(string(ext.PeerPort)))
+	assert.Equal(t, true, sp.Tag("observed"))
+	assert.Equal(t, true, sp.Tag(string(ext.Error)))
+	assert.Equal(t, "error", sp.Logs()[0].Fields[0].ValueString)
+}
+
+func TestTransport_LateClientTrace(t *testing.T) {
+	tr := mocktracer.New()
+	var trace *httptrace.ClientTrace
+	transport := NewTransport(tr, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		trace = httptrace.ContextClientTrace(req.Context())
+		return nil, errors.New("connection refused")
+	}), ClientTrace(true))
+	_, err := transport.RoundTrip(httptest.NewRequest("GET", "http://example.com", nil))
+	require.Error(t, err)
+
+	require.NotNil(t, trace)
+	trace.ConnectDone("tcp", "example.com:80", nil)
+	trace.GotConn(httptrace.GotConnInfo{})
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Len(t, spans[0].Logs(), 1, "only the error is logged")
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+func (failingBody) Close() error             { return nil }
+
+func TestTransport_ReadAfterClose(t *testing.T) {
+	tr := mocktracer.New()
+	transport := NewTransport(tr, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: failingBody{}}, nil
+	}))
+	resp, err := transport.RoundTrip(httptest.NewRequest("GET", "http://example.com", nil))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	_, err = resp.Body.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Empty(t, spans[0].Logs())
+	assert.Nil(t, spans[0].Tag(string(ext.Error)))
+}
@@ -0,0 +1,94 @@
+package nethttp
+
+import "net/http"
+
+// responseTracker records the status code and the number of body bytes
+// written through an http.ResponseWriter.
+type responseTracker struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *responseTracker) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseTracker) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// wrapped returns an http.ResponseWriter that records through w while
+// exposing exactly the optional interfaces (http.Flusher, http.Hijacker and
+// http.Pusher) that the underlying ResponseWriter implements, so that
+// handlers' type assertions behave as they would without the middleware.
+func (w *responseTracker) wrapped() http.ResponseWriter {
+	var (
+		flusher, isFlusher   = w.ResponseWriter.(http.Flusher)
+		hijacker, isHijacker = w.ResponseWriter.(http.Hijacker)
+		pusher, isPusher     = w.ResponseWriter.(http.Pusher)
+	)
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, trackedFlusher{w, flusher}, hijacker, pusher}
+	case isFlusher && isHijacker:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, trackedFlusher{w, flusher}, hijacker}
+	case isFlusher && isPusher:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Pusher
+		}{w, trackedFlusher{w, flusher}, pusher}
+	case isHijacker && isPusher:
+		return struct {
+			http.ResponseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, hijacker, pusher}
+	case isFlusher:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+		}{w, trackedFlusher{w, flusher}}
+	case isHijacker:
+		return struct {
+			http.ResponseWriter
+			http.Hijacker
+		}{w, hijacker}
+	case isPusher:
+		return struct {
+			http.ResponseWriter
+			http.Pusher
+		}{w, pusher}
+	}
+	return w
+}
+
+// trackedFlusher marks the header as written, since flushing commits the
+// implicit 200 status.
+type trackedFlusher struct {
+	tracker *responseTracker
+	flusher http.Flusher
+}
+
+func (f trackedFlusher) Flush() {
+	f.tracker.wroteHeader = true
+	f.flusher.Flush()
+}
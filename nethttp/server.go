@@ -0,0 +1,151 @@
+// Package nethttp provides OpenTracing instrumentation for net/http servers
+// and clients.
+package nethttp
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const defaultComponentName = "net/http"
+
+// responseSizeTag records the number of response body bytes written.
+const responseSizeTag = "http.response_size"
+
+type mwOptions struct {
+	opNameFunc    func(r *http.Request) string
+	spanFilter    func(r *http.Request) bool
+	spanObserver  func(span opentracing.Span, r *http.Request)
+	urlTagFunc    func(u *url.URL) string
+	componentName string
+}
+
+// MWOption controls the behavior of the Middleware.
+type MWOption func(*mwOptions)
+
+// OperationNameFunc returns a MWOption that uses given function f
+// to generate operation name for each server-side span.
+func OperationNameFunc(f func(r *http.Request) string) MWOption {
+	return func(options *mwOptions) {
+		options.opNameFunc = f
+	}
+}
+
+// MWComponentName returns a MWOption that sets the component name
+// for the server-side span.
+func MWComponentName(componentName string) MWOption {
+	return func(options *mwOptions) {
+		options.componentName = componentName
+	}
+}
+
+// MWSpanFilter returns a MWOption that filters requests from creating a span
+// for the server-side span. A span is only created if the filter returns
+// true.
+func MWSpanFilter(f func(r *http.Request) bool) MWOption {
+	return func(options *mwOptions) {
+		options.spanFilter = f
+	}
+}
+
+// MWSpanObserver returns a MWOption that observes the span
+// for the server-side span, e.g. to set additional tags.
+func MWSpanObserver(f func(span opentracing.Span, r *http.Request)) MWOption {
+	return func(options *mwOptions) {
+		options.spanObserver = f
+	}
+}
+
+// MWURLTagFunc returns a MWOption that uses given function f to set the
+// span's http.url tag. It can be used to redact sensitive parts of the URL,
+// such as query parameters.
+func MWURLTagFunc(f func(u *url.URL) string) MWOption {
+	return func(options *mwOptions) {
+		options.urlTagFunc = f
+	}
+}
+
+// Middleware wraps an http.Handler and traces incoming requests.
+// Additionally, it adds the span to the request's context.
+//
+// By default, the operation name of the spans is set to "HTTP {method}".
+// This can be overridden with options.
+//
+// Example:
+//
+//     http.ListenAndServe("localhost:80", nethttp.Middleware(tracer, http.DefaultServeMux))
+//
+// The options allow fine tuning the behavior of the middleware.
+//
+// Example:
+//
+//     mw := nethttp.Middleware(
+//         tracer,
+//         http.DefaultServeMux,
+//         nethttp.OperationNameFunc(func(r *http.Request) string {
+//             return "HTTP " + r.Method + ":/api/customers"
+//         }),
+//         nethttp.MWSpanObserver(func(sp opentracing.Span, r *http.Request) {
+//             sp.SetTag("http.uri", r.URL.EscapedPath())
+//         }),
+//     )
+func Middleware(tr opentracing.Tracer, h http.Handler, options ...MWOption) http.Handler {
+	return MiddlewareFunc(tr, h.ServeHTTP, options...)
+}
+
+// MiddlewareFunc wraps an http.HandlerFunc and traces incoming requests.
+// It behaves identically to the Middleware function above.
+func MiddlewareFunc(tr opentracing.Tracer, h http.HandlerFunc, options ...MWOption) http.HandlerFunc {
+	opts := mwOptions{
+		opNameFunc: func(r *http.Request) string {
+			return "HTTP " + r.Method
+		},
+		spanFilter:   func(r *http.Request) bool { return true },
+		spanObserver: func(span opentracing.Span, r *http.Request) {},
+		urlTagFunc: func(u *url.URL) string {
+			return u.String()
+		},
+		componentName: defaultComponentName,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !opts.spanFilter(r) {
+			h(w, r)
+			return
+		}
+		ctx, _ := tr.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+		sp := tr.StartSpan(opts.opNameFunc(r), ext.RPCServerOption(ctx))
+		ext.HTTPMethod.Set(sp, r.Method)
+		ext.HTTPUrl.Set(sp, opts.urlTagFunc(r.URL))
+		ext.Component.Set(sp, opts.componentName)
+		opts.spanObserver(sp, r)
+
+		tracker := &responseTracker{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(opentracing.ContextWithSpan(r.Context(), sp))
+
+		defer func() {
+			panicErr := recover()
+			status := tracker.status
+			if panicErr != nil {
+				status = http.StatusInternalServerError
+			}
+			ext.HTTPStatusCode.Set(sp, uint16(status))
+			sp.SetTag(responseSizeTag, tracker.size)
+			if status >= http.StatusInternalServerError {
+				ext.Error.Set(sp, true)
+			}
+			sp.Finish()
+			if panicErr != nil {
+				panic(panicErr)
+			}
+		}()
+
+		h(tracker.wrapped(), r)
+	}
+	return http.HandlerFunc(fn)
+}
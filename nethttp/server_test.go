@@ -0,0 +1,162 @@
+package nethttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestMiddleware_Tags(t *testing.T) {
+	tr := mocktracer.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		assert.NotNil(t, opentracing.SpanFromContext(r.Context()))
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(Middleware(tr, mux))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status uint16
+		size   int64
+		err    interface{}
+	}{
+		{"/ok?token=secret", 200, 5, nil},
+		{"/fail", 503, 0, true},
+		{"/missing", 404, 19, nil},
+	}
+	for _, test := range tests {
+		tr.Reset()
+		resp, err := http.Get(srv.URL + test.path)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		spans := tr.FinishedSpans()
+		require.Len(t, spans, 1, test.path)
+		sp := spans[0]
+		assert.Equal(t, "HTTP GET", sp.OperationName)
+		assert.Equal(t, map[string]interface{}{
+			string(ext.SpanKind):       ext.SpanKindRPCServerEnum,
+			string(ext.Component):      defaultComponentName,
+			string(ext.HTTPMethod):     "GET",
+			string(ext.HTTPUrl):        test.path,
+			string(ext.HTTPStatusCode): test.status,
+			responseSizeTag:            test.size,
+		}, withoutTag(sp.Tags(), string(ext.Error)), test.path)
+		assert.Equal(t, test.err, sp.Tag(string(ext.Error)), test.path)
+	}
+}
+
+func withoutTag(tags map[string]interface{}, key string) map[string]interface{} {
+	delete(tags, key)
+	return tags
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	tr := mocktracer.New()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mw := Middleware(tr, h,
+		OperationNameFunc(func(r *http.Request) string { return "op:" + r.URL.Path }),
+		MWComponentName("my-server"),
+		MWURLTagFunc(func(u *url.URL) string {
+			redacted := *u
+			redacted.RawQuery = ""
+			return redacted.String()
+		}),
+		MWSpanFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }),
+		MWSpanObserver(func(sp opentracing.Span, r *http.Request) {
+			sp.SetTag("observed", true)
+		}),
+	)
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	assert.Empty(t, tr.FinishedSpans())
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/x?token=secret", nil))
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "op:/x", spans[0].OperationName)
+	assert.Equal(t, "/x", spans[0].Tag(string(ext.HTTPUrl)))
+	assert.Equal(t, "my-server", spans[0].Tag(string(ext.Component)))
+	assert.Equal(t, true, spans[0].Tag("observed"))
+}
+
+func TestMiddleware_ExtractsParent(t *testing.T) {
+	tr := mocktracer.New()
+	parent := tr.StartSpan("client")
+	req := httptest.NewRequest("GET", "/", nil)
+	require.NoError(t, tr.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header)))
+
+	Middleware(tr, http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	parentCtx := parent.Context().(mocktracer.MockSpanContext)
+	assert.Equal(t, parentCtx.TraceID, spans[0].SpanContext.TraceID)
+	assert.Equal(t, parentCtx.SpanID, spans[0].ParentID)
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	tr := mocktracer.New()
+	mw := Middleware(tr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	assert.Panics(t, func() {
+		mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint16(500), spans[0].Tag(string(ext.HTTPStatusCode)))
+	assert.Equal(t, true, spans[0].Tag(string(ext.Error)))
+}
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (pushRecorder) Push(target string, opts *http.PushOptions) error { return nil }
+
+func TestMiddleware_ResponseWriterInterfaces(t *testing.T) {
+	tr := mocktracer.New()
+	var isFlusher, isHijacker, isPusher bool
+	mw := Middleware(tr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isFlusher = w.(http.Flusher)
+		_, isHijacker = w.(http.Hijacker)
+		_, isPusher = w.(http.Pusher)
+		w.(http.Flusher).Flush()
+		w.WriteHeader(http.StatusTeapot) // superfluous after Flush
+	}))
+
+	mw.ServeHTTP(pushRecorder{httptest.NewRecorder()}, httptest.NewRequest("GET", "/", nil))
+	assert.True(t, isFlusher)
+	assert.False(t, isHijacker)
+	assert.True(t, isPusher)
+	assert.Equal(t, uint16(200), tr.FinishedSpans()[0].Tag(string(ext.HTTPStatusCode)))
+
+	srv := httptest.NewServer(Middleware(tr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isFlusher = w.(http.Flusher)
+		_, isHijacker = w.(http.Hijacker)
+		_, isPusher = w.(http.Pusher)
+	})))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.True(t, isFlusher)
+	assert.True(t, isHijacker)
+	assert.False(t, isPusher, "HTTP/1.1 connections don't support Push")
+}
@@ -8,12 +8,13 @@ import (
 
 func TestChildOfAndFollowsFrom(t *testing.T) {
 	tests := []struct {
-		newOpt  func(SpanContext) SpanReference
+		newOpt  func(SpanContext) StartSpanOption
 		refType SpanReferenceType
 		name    string
 	}{
-		{ChildOf, ChildOfRef, "ChildOf"},
-		{FollowsFrom, FollowsFromRef, "FollowsFrom"},
+		{func(sc SpanContext) StartSpanOption { return ChildOf(sc) }, ChildOfRef, "ChildOf"},
+		{func(sc SpanContext) StartSpanOption { return FollowsFrom(sc) }, FollowsFromRef, "FollowsFrom"},
+		{func(sc SpanContext) StartSpanOption { return Link(sc, nil) }, LinkRef, "Link"},
 	}
 
 	for _, test := range tests {
@@ -27,5 +28,31 @@ func TestChildOfAndFollowsFrom(t *testing.T) {
 		require.Equal(t, []SpanReference{
 			SpanReference{ReferencedContext: ctx, Type: test.refType},
 		}, opts.References, "%s(ctx) must append a reference", test.name)
+		require.Nil(t, opts.ReferenceAttributes)
 	}
 }
+
+func TestAttributedReference(t *testing.T) {
+	ctx := new(noopSpanContext)
+	opts := new(StartSpanOptions)
+	ChildOf(ctx).Apply(opts)
+	FollowsFrom(ctx).WithAttributes(Tags{"a": 1}).Apply(opts)
+	Link(nil, Tags{"reason": "batch"}).Apply(opts)
+	Link(ctx, Tags{"reason": "batch"}).Apply(opts)
+	Link(ctx, nil).Apply(opts)
+
+	require.Equal(t, []SpanReference{
+		{ChildOfRef, ctx},
+		{FollowsFromRef, ctx},
+		{LinkRef, ctx},
+		{LinkRef, ctx},
+	}, opts.References)
+	require.Equal(t, []Tags{nil, {"a": 1}, {"reason": "batch"}}, opts.ReferenceAttributes)
+	require.Equal(t, []AttributedReference{
+		{SpanReference{ChildOfRef, ctx}, nil},
+		{SpanReference{FollowsFromRef, ctx}, Tags{"a": 1}},
+		{SpanReference{LinkRef, ctx}, Tags{"reason": "batch"}},
+		{SpanReference{LinkRef, ctx}, nil},
+	}, opts.AttributedReferences())
+	require.Nil(t, StartSpanOptions{}.AttributedReferences())
+}
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// Funcs adapts a pair of functions to the Processor interface. Either may
+// be nil.
+type Funcs struct {
+	Start  func(span *SpanData) (keep bool)
+	Finish func(span *SpanData)
+}
+
+// OnStart belongs to the Processor interface.
+func (f Funcs) OnStart(span *SpanData) bool {
+	if f.Start == nil {
+		return true
+	}
+	return f.Start(span)
+}
+
+// OnFinish belongs to the Processor interface.
+func (f Funcs) OnFinish(span *SpanData) {
+	if f.Finish != nil {
+		f.Finish(span)
+	}
+}
+
+// DefaultTags returns a Processor that adds `tags` to every span at start,
+// unless the span already has a tag with the same key.
+func DefaultTags(tags opentracing.Tags) Processor {
+	return Funcs{Start: func(span *SpanData) bool {
+		for k, v := range tags {
+			if _, ok := span.Tags[k]; !ok {
+				span.Tags[k] = v
+			}
+		}
+		return true
+	}}
+}
+
+// DropOperations returns a Processor that drops spans with any of the given
+// operation names.
+func DropOperations(operationNames ...string) Processor {
+	drop := make(map[string]bool, len(operationNames))
+	for _, name := range operationNames {
+		drop[name] = true
+	}
+	return DropIf(func(span *SpanData) bool {
+		return drop[span.OperationName]
+	})
+}
+
+// DropIf returns a Processor that drops spans for which `f` returns true at
+// start.
+func DropIf(f func(span *SpanData) bool) Processor {
+	return Funcs{Start: func(span *SpanData) bool {
+		return !f(span)
+	}}
+}
+
+// RenameOperations returns a Processor that replaces the operation name of
+// every span with the result of `f`. Names set with Span.SetOperationName()
+// are renamed at finish.
+func RenameOperations(f func(operationName string) string) Processor {
+	return Funcs{
+		Start: func(span *SpanData) bool {
+			span.OperationName = f(span.OperationName)
+			return true
+		},
+		Finish: func(span *SpanData) {
+			if span.OperationNameChanged {
+				span.OperationName = f(span.OperationName)
+			}
+		},
+	}
+}
+
+// Redacted replaces the values of tags scrubbed by RedactTags.
+const Redacted = "[REDACTED]"
+
+// RedactTags returns a Processor that replaces the values of the given tags
+// with Redacted, both at start and at finish.
+func RedactTags(keys ...string) Processor {
+	return ScrubTags(func(key string, value interface{}) interface{} {
+		for _, k := range keys {
+			if k == key {
+				return Redacted
+			}
+		}
+		return value
+	})
+}
+
+// ScrubTags returns a Processor that replaces the value of every tag with
+// the result of `f`, both at start and at finish.
+func ScrubTags(f func(key string, value interface{}) interface{}) Processor {
+	scrub := func(span *SpanData) {
+		for k, v := range span.Tags {
+			span.Tags[k] = f(k, v)
+		}
+	}
+	return Funcs{
+		Start: func(span *SpanData) bool {
+			scrub(span)
+			return true
+		},
+		Finish: scrub,
+	}
+}
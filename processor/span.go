@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// span buffers operation name and tag changes until Finish(), so that
+// processors see them in OnFinish. Everything else is delegated to the inner
+// span right away.
+type span struct {
+	tracer *Tracer
+	inner  opentracing.Span
+
+	sync.Mutex
+	name      string
+	startName string           // operation name of the inner span
+	renamed   bool             // name was set after start
+	tags      opentracing.Tags // all tags
+	applied   opentracing.Tags // tags the inner span has
+	startTime time.Time
+}
+
+func (s *span) Context() opentracing.SpanContext {
+	return s.inner.Context()
+}
+
+func (s *span) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	s.name = operationName
+	s.renamed = true
+	s.Unlock()
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	s.tags[key] = value
+	s.Unlock()
+	if isSamplingPriority(key) {
+		s.inner.SetTag(key, value)
+		s.Lock()
+		s.applied[key] = value
+		s.Unlock()
+	}
+	return s
+}
+
+func (s *span) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
+	s.Lock()
+	data := &SpanData{
+		OperationName:        s.name,
+		OperationNameChanged: s.renamed,
+		Tags:                 copyTags(s.tags),
+		StartTime:            s.startTime,
+	}
+	s.Unlock()
+	for _, p := range s.tracer.processors {
+		p.OnFinish(data)
+		if data.Tags == nil {
+			data.Tags = opentracing.Tags{}
+		}
+	}
+
+	s.Lock()
+	if data.OperationName != s.startName {
+		s.inner.SetOperationName(data.OperationName)
+	}
+	for k, v := range data.Tags {
+		if applied, ok := s.applied[k]; !ok || !reflect.DeepEqual(applied, v) {
+			s.inner.SetTag(k, v)
+		}
+	}
+	s.Unlock()
+	s.inner.FinishWithOptions(opts)
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.inner.SetBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *span) BaggageItem(restrictedKey string) string {
+	return s.inner.BaggageItem(restrictedKey)
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	s.inner.LogFields(fields...)
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	s.inner.LogKV(alternatingKeyValues...)
+}
+
+func (s *span) LogEvent(event string) {
+	s.inner.LogEvent(event)
+}
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	s.inner.LogEventWithPayload(event, payload)
+}
+
+func (s *span) Log(data opentracing.LogData) {
+	s.inner.Log(data)
+}
+
+func (s *span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// droppedSpan stands in for a span dropped by a processor. Its context is
+// that of its parent, if any, so that its children and injected carriers
+// continue the trace of the parent.
+type droppedSpan struct {
+	opentracing.Span // noop
+	tracer           *Tracer
+	ctx              opentracing.SpanContext
+}
+
+func newDroppedSpan(t *Tracer, refs []opentracing.AttributedReference) *droppedSpan {
+	noop := opentracing.NoopTracer{}.StartSpan("")
+	s := &droppedSpan{Span: noop, tracer: t, ctx: noop.Context()}
+	for _, ref := range refs {
+		if ref.Type == opentracing.ChildOfRef && ref.ReferencedContext != nil {
+			s.ctx = ref.ReferencedContext
+			return s
+		}
+	}
+	for _, ref := range refs {
+		if ref.Type == opentracing.FollowsFromRef && ref.ReferencedContext != nil {
+			s.ctx = ref.ReferencedContext
+			return s
+		}
+	}
+	return s
+}
+
+func (s *droppedSpan) Context() opentracing.SpanContext {
+	return s.ctx
+}
+
+func (s *droppedSpan) SetOperationName(string) opentracing.Span {
+	return s
+}
+
+func (s *droppedSpan) SetTag(string, interface{}) opentracing.Span {
+	return s
+}
+
+func (s *droppedSpan) SetBaggageItem(string, string) opentracing.Span {
+	return s
+}
+
+func (s *droppedSpan) BaggageItem(restrictedKey string) string {
+	var value string
+	s.ctx.ForeachBaggageItem(func(k, v string) bool {
+		if k == restrictedKey {
+			value = v
+			return false
+		}
+		return true
+	})
+	return value
+}
+
+func (s *droppedSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
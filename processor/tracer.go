@@ -0,0 +1,152 @@
+// Package processor provides a Tracer decorator that runs a chain of span
+// processors between callers and any opentracing.Tracer, e.g. to add default
+// tags, drop or rename operations, or scrub sensitive tag values:
+//
+//     tracer := processor.New(inner,
+//         processor.DefaultTags(opentracing.Tags{"service.version": version}),
+//         processor.DropOperations("GET /healthz"),
+//         processor.RedactTags("http.authorization"),
+//     )
+//
+// Span contexts are those of the inner tracer, so Inject() and Extract()
+// work as before, and spans of both tracers may refer to each other.
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// SpanData is the view of a span that processors inspect and modify.
+type SpanData struct {
+	OperationName string
+
+	// OperationNameChanged is set in OnFinish if the operation name was set
+	// with Span.SetOperationName() after start, in which case OnStart did
+	// not process it.
+	OperationNameChanged bool
+
+	// Tags holds all tags of the span. Never nil.
+	Tags opentracing.Tags
+
+	// References and StartTime are only taken into account by OnStart.
+	// Every reference carries its attributes, if any, so that processors
+	// may filter or reorder References without losing them.
+	References []opentracing.AttributedReference
+	StartTime  time.Time
+}
+
+// Processor is a stage of the processing pipeline.
+type Processor interface {
+	// OnStart is called before the inner tracer starts the span. It may
+	// modify `span`. If it returns false, the span is dropped: later
+	// processors are skipped, and the inner tracer never sees the span.
+	OnStart(span *SpanData) (keep bool)
+
+	// OnFinish is called before the inner tracer finishes the span, with
+	// the tags set since start added to span.Tags. It may modify the
+	// operation name and the tags. Removing a tag that was already present
+	// at start has no effect, since the inner span already has it.
+	OnFinish(span *SpanData)
+}
+
+// Tracer is an opentracing.Tracer that runs processors on the spans it
+// starts and finishes with an inner tracer.
+type Tracer struct {
+	inner      opentracing.Tracer
+	processors []Processor
+}
+
+// New returns a Tracer delegating to `inner` that runs `processors`, in
+// order, on every span.
+func New(inner opentracing.Tracer, processors ...Processor) *Tracer {
+	return &Tracer{inner: inner, processors: processors}
+}
+
+// Inner returns the inner tracer.
+func (t *Tracer) Inner() opentracing.Tracer {
+	return t.inner
+}
+
+// StartSpan belongs to the Tracer interface.
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	sso := opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&sso)
+	}
+	data := &SpanData{
+		OperationName: operationName,
+		Tags:          make(opentracing.Tags, len(sso.Tags)),
+		References:    sso.AttributedReferences(),
+		StartTime:     sso.StartTime,
+	}
+	for k, v := range sso.Tags {
+		data.Tags[k] = v
+	}
+	for _, p := range t.processors {
+		if !p.OnStart(data) {
+			return newDroppedSpan(t, data.References)
+		}
+		if data.Tags == nil {
+			data.Tags = opentracing.Tags{}
+		}
+	}
+
+	innerOpts := make([]opentracing.StartSpanOption, 0, len(data.References)+2)
+	for _, ref := range data.References {
+		innerOpts = append(innerOpts, ref) // keeps the attributes
+	}
+	innerOpts = append(innerOpts, copyTags(data.Tags))
+	if !data.StartTime.IsZero() {
+		innerOpts = append(innerOpts, opentracing.StartTime(data.StartTime))
+	}
+	return &span{
+		tracer:    t,
+		inner:     t.inner.StartSpan(data.OperationName, innerOpts...),
+		name:      data.OperationName,
+		startName: data.OperationName,
+		tags:      data.Tags,
+		applied:   copyTags(data.Tags),
+		startTime: data.StartTime,
+	}
+}
+
+// Inject belongs to the Tracer interface.
+func (t *Tracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	return t.inner.Inject(sc, format, carrier)
+}
+
+// Extract belongs to the Tracer interface.
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	return t.inner.Extract(format, carrier)
+}
+
+// ContextWithSpanHook implements opentracing.TracerContextWithSpanExtension
+// by calling the inner tracer's hook, if any, with the inner span.
+func (t *Tracer) ContextWithSpanHook(ctx context.Context, sp opentracing.Span) context.Context {
+	hook, ok := t.inner.(opentracing.TracerContextWithSpanExtension)
+	if !ok {
+		return ctx
+	}
+	if s, ok := sp.(*span); ok {
+		return hook.ContextWithSpanHook(ctx, s.inner)
+	}
+	return ctx
+}
+
+func copyTags(tags opentracing.Tags) opentracing.Tags {
+	c := make(opentracing.Tags, len(tags))
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
+
+// isSamplingPriority reports whether the tag must reach the inner span
+// immediately, since it affects propagation.
+func isSamplingPriority(key string) bool {
+	return key == string(ext.SamplingPriority)
+}
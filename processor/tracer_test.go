@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/harness"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type mockProbe struct{}
+
+func (mockProbe) SameTrace(first, second opentracing.Span) bool {
+	return first.Context().(mocktracer.MockSpanContext).TraceID ==
+		second.Context().(mocktracer.MockSpanContext).TraceID
+}
+
+func (mockProbe) SameSpanContext(span opentracing.Span, sc opentracing.SpanContext) bool {
+	mockCtx, ok := sc.(mocktracer.MockSpanContext)
+	if !ok {
+		return false
+	}
+	spanCtx := span.Context().(mocktracer.MockSpanContext)
+	return spanCtx.TraceID == mockCtx.TraceID && spanCtx.SpanID == mockCtx.SpanID
+}
+
+func TestAPICheck(t *testing.T) {
+	harness.RunAPIChecks(t, func() (opentracing.Tracer, func()) {
+		return New(mocktracer.New(), DefaultTags(opentracing.Tags{"env": "test"})), nil
+	},
+		harness.CheckEverything(),
+		harness.UseProbe(mockProbe{}),
+	)
+}
+
+func TestProcessors(t *testing.T) {
+	inner := mocktracer.New()
+	tracer := New(inner,
+		DefaultTags(opentracing.Tags{"env": "test", "component": "default"}),
+		DropOperations("GET /healthz"),
+		RenameOperations(func(name string) string { return "svc." + name }),
+		RedactTags("password"),
+	)
+
+	parent := tracer.StartSpan("parent", opentracing.Tag{Key: "component", Value: "explicit"})
+	parent.SetTag("password", "hunter2").SetTag("user", "alice")
+	parent.SetOperationName("renamed")
+	dropped := tracer.StartSpan("GET /healthz", opentracing.ChildOf(parent.Context()))
+	dropped.SetTag("ignored", true).Finish()
+	child := tracer.StartSpan("child", opentracing.ChildOf(dropped.Context()), opentracing.Tag{Key: "password", Value: "secret"})
+	assert.Equal(t, tracer, child.Tracer())
+	child.Finish()
+	parent.Finish()
+
+	spans := inner.FinishedSpans()
+	require.Len(t, spans, 2)
+	c, p := spans[0], spans[1]
+	assert.Equal(t, "svc.child", c.OperationName)
+	assert.Equal(t, map[string]interface{}{"env": "test", "component": "default", "password": Redacted}, c.Tags())
+	assert.Equal(t, p.SpanContext.SpanID, c.ParentID, "children of dropped spans attach to the dropped span's parent")
+
+	assert.Equal(t, "svc.renamed", p.OperationName)
+	assert.Equal(t, map[string]interface{}{"env": "test", "component": "explicit", "password": Redacted, "user": "alice"}, p.Tags())
+}
+
+func TestContextWorksWithInnerTracer(t *testing.T) {
+	inner := mocktracer.New()
+	tracer := New(inner)
+
+	span := tracer.StartSpan("op")
+	span.SetBaggageItem("k", "v")
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, inner.Inject(span.Context(), opentracing.TextMap, carrier))
+	sc, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+
+	child := inner.StartSpan("child", opentracing.ChildOf(sc))
+	assert.Equal(t, "v", child.BaggageItem("k"))
+	child.Finish()
+	span.Finish()
+	spans := inner.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext.SpanID, spans[0].ParentID)
+}
+
+func TestDroppedSpan(t *testing.T) {
+	inner := mocktracer.New()
+	tracer := New(inner, DropOperations("dropped"))
+
+	root := tracer.StartSpan("dropped")
+	assert.Equal(t, opentracing.ErrInvalidSpanContext,
+		tracer.Inject(root.Context(), opentracing.TextMap, opentracing.TextMapCarrier{}))
+	root.Finish()
+
+	parent := tracer.StartSpan("parent")
+	parent.SetBaggageItem("k", "v")
+	dropped := tracer.StartSpan("dropped", opentracing.FollowsFrom(parent.Context()))
+	assert.Equal(t, "v", dropped.BaggageItem("k"))
+	assert.Equal(t, "", dropped.BaggageItem("missing"))
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(dropped.Context(), opentracing.TextMap, carrier))
+	sc, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, sc.(mocktracer.MockSpanContext).SpanID)
+	assert.Len(t, inner.UnfinishedSpans(), 1)
+}
+
+func TestSamplingPriorityIsAppliedImmediately(t *testing.T) {
+	inner := mocktracer.New()
+	tracer := New(inner)
+	span := tracer.StartSpan("op")
+	ext.SamplingPriority.Set(span, 0)
+	assert.False(t, span.Context().(mocktracer.MockSpanContext).Sampled)
+	span.Finish()
+}
+
+type hookTracer struct {
+	*mocktracer.MockTracer
+	hooked opentracing.Span
+}
+
+func (t *hookTracer) ContextWithSpanHook(ctx context.Context, span opentracing.Span) context.Context {
+	t.hooked = span
+	return ctx
+}
+
+func TestContextWithSpanHook(t *testing.T) {
+	inner := &hookTracer{MockTracer: mocktracer.New()}
+	tracer := New(inner)
+	span := tracer.StartSpan("op")
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	assert.Equal(t, span, opentracing.SpanFromContext(ctx))
+	assert.IsType(t, &mocktracer.MockSpan{}, inner.hooked, "the inner tracer's hook must see its own span")
+}
+
+func TestReferenceAttributes(t *testing.T) {
+	inner := mocktracer.New()
+	dropChildOf := Funcs{Start: func(span *SpanData) bool {
+		refs := span.References[:0]
+		for _, ref := range span.References {
+			if ref.Type != opentracing.ChildOfRef {
+				refs = append(refs, ref)
+			}
+		}
+		span.References = refs
+		return true
+	}}
+	tracer := New(inner, dropChildOf)
+
+	parent := inner.StartSpan("parent")
+	linked := inner.StartSpan("linked")
+	tracer.StartSpan("child",
+		opentracing.ChildOf(parent.Context()),
+		opentracing.Link(linked.Context(), opentracing.Tags{"reason": "batch"}),
+	).Finish()
+
+	refs := inner.FinishedSpans()[0].References()
+	require.Len(t, refs, 1)
+	assert.Equal(t, opentracing.LinkRef, refs[0].Type)
+	assert.Equal(t, linked.Context(), refs[0].ReferencedContext)
+	assert.Equal(t, opentracing.Tags{"reason": "batch"}, refs[0].Attributes)
+}
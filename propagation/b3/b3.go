@@ -0,0 +1,325 @@
+// Package b3 implements the Zipkin B3 propagation format
+// (https://github.com/openzipkin/b3-propagation), in both its multi-header
+// (`X-B3-TraceId`, `X-B3-SpanId`, ...) and single-header (`b3`) forms.
+//
+// Like the other packages under propagation/, this is a codec rather than a
+// Tracer: it reads and writes headers through
+// opentracing.TextMapReader/TextMapWriter and leaves the mapping to a
+// concrete opentracing.SpanContext to the tracer implementation.
+package b3
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Header names used by the multi-header and single-header forms.
+const (
+	TraceIDHeader      = "X-B3-TraceId"
+	SpanIDHeader       = "X-B3-SpanId"
+	ParentSpanIDHeader = "X-B3-ParentSpanId"
+	SampledHeader      = "X-B3-Sampled"
+	FlagsHeader        = "X-B3-Flags"
+	SingleHeader       = "b3"
+)
+
+// SamplingState is the sampling decision carried by B3 headers.
+type SamplingState int
+
+const (
+	// SamplingUnset means that the upstream made no sampling decision.
+	SamplingUnset SamplingState = iota
+
+	// SamplingDeny means that the trace must not be sampled.
+	SamplingDeny
+
+	// SamplingAccept means that the trace is sampled.
+	SamplingAccept
+
+	// SamplingDebug means that the trace is sampled and should bypass any
+	// downstream sampling or rate limiting. It implies SamplingAccept.
+	SamplingDebug
+)
+
+// Sampled reports whether the state is SamplingAccept or SamplingDebug.
+func (s SamplingState) Sampled() bool {
+	return s == SamplingAccept || s == SamplingDebug
+}
+
+// Context is the information carried by B3 headers.
+//
+// B3 allows propagating only a sampling decision, without any IDs. Such a
+// Context has a zero TraceID and SpanID; see IsValid.
+type Context struct {
+	// TraceIDHigh holds the upper 64 bits of a 128-bit trace ID. It is zero
+	// for 64-bit trace IDs.
+	TraceIDHigh uint64
+
+	// TraceID holds the lower (or only) 64 bits of the trace ID.
+	TraceID uint64
+
+	SpanID uint64
+
+	// ParentSpanID is optional; zero means absent.
+	ParentSpanID uint64
+
+	Sampling SamplingState
+}
+
+// IsValid reports whether the Context carries trace and span IDs, as
+// opposed to only a sampling decision.
+func (c Context) IsValid() bool {
+	return (c.TraceID != 0 || c.TraceIDHigh != 0) && c.SpanID != 0
+}
+
+// Is128Bit reports whether the Context carries a 128-bit trace ID.
+func (c Context) Is128Bit() bool {
+	return c.TraceIDHigh != 0
+}
+
+// InjectMulti writes `c` to `carrier` using the multi-header form. A Context
+// without IDs only writes its sampling decision, if any.
+func InjectMulti(c Context, carrier opentracing.TextMapWriter) error {
+	if c.IsValid() {
+		carrier.Set(TraceIDHeader, formatTraceID(c))
+		carrier.Set(SpanIDHeader, formatID(c.SpanID))
+		if c.ParentSpanID != 0 {
+			carrier.Set(ParentSpanIDHeader, formatID(c.ParentSpanID))
+		}
+	}
+	switch c.Sampling {
+	case SamplingDeny:
+		carrier.Set(SampledHeader, "0")
+	case SamplingAccept:
+		carrier.Set(SampledHeader, "1")
+	case SamplingDebug:
+		// Debug implies accept, so X-B3-Sampled is redundant.
+		carrier.Set(FlagsHeader, "1")
+	}
+	return nil
+}
+
+// InjectSingle writes `c` to `carrier` using the single `b3` header form:
+//
+//     b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+//
+// where the last two fields are optional. A Context without IDs is written
+// as a bare sampling state ("0", "1" or "d"); if it has no sampling decision
+// either, nothing is written.
+func InjectSingle(c Context, carrier opentracing.TextMapWriter) error {
+	sampling := ""
+	switch c.Sampling {
+	case SamplingDeny:
+		sampling = "0"
+	case SamplingAccept:
+		sampling = "1"
+	case SamplingDebug:
+		sampling = "d"
+	}
+	if !c.IsValid() {
+		if sampling != "" {
+			carrier.Set(SingleHeader, sampling)
+		}
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(formatTraceID(c))
+	sb.WriteByte('-')
+	sb.WriteString(formatID(c.SpanID))
+	if sampling != "" {
+		sb.WriteByte('-')
+		sb.WriteString(sampling)
+		if c.ParentSpanID != 0 {
+			sb.WriteByte('-')
+			sb.WriteString(formatID(c.ParentSpanID))
+		}
+	}
+	carrier.Set(SingleHeader, sb.String())
+	return nil
+}
+
+// Extract reads a Context from `carrier`, accepting either form. Header
+// names are matched case-insensitively. If both forms are present, the
+// single `b3` header takes precedence, as the B3 spec recommends.
+//
+// Extract returns opentracing.ErrSpanContextNotFound if there are no B3
+// headers, and opentracing.ErrSpanContextCorrupted if they are malformed or
+// inconsistent (e.g. a trace ID without a span ID).
+func Extract(carrier opentracing.TextMapReader) (Context, error) {
+	var (
+		single                                   string
+		traceID, spanID, parentID, sampled, flag string
+		found, foundSingle                       bool
+	)
+	err := carrier.ForeachKey(func(key, val string) error {
+		switch strings.ToLower(key) {
+		case "b3":
+			single, foundSingle = val, true
+		case "x-b3-traceid":
+			traceID, found = val, true
+		case "x-b3-spanid":
+			spanID, found = val, true
+		case "x-b3-parentspanid":
+			parentID, found = val, true
+		case "x-b3-sampled":
+			sampled, found = val, true
+		case "x-b3-flags":
+			flag, found = val, true
+		}
+		return nil
+	})
+	if err != nil {
+		return Context{}, err
+	}
+	if foundSingle {
+		return parseSingle(single)
+	}
+	if !found {
+		return Context{}, opentracing.ErrSpanContextNotFound
+	}
+	return parseMulti(traceID, spanID, parentID, sampled, flag)
+}
+
+func parseMulti(traceID, spanID, parentID, sampled, flag string) (Context, error) {
+	var c Context
+	var err error
+	switch flag {
+	case "":
+	case "1":
+		c.Sampling = SamplingDebug
+	case "0":
+	default:
+		return Context{}, opentracing.ErrSpanContextCorrupted
+	}
+	if c.Sampling != SamplingDebug {
+		switch strings.ToLower(sampled) {
+		case "":
+		case "1", "true":
+			c.Sampling = SamplingAccept
+		case "0", "false":
+			c.Sampling = SamplingDeny
+		default:
+			return Context{}, opentracing.ErrSpanContextCorrupted
+		}
+	}
+	if traceID == "" && spanID == "" {
+		if parentID != "" {
+			return Context{}, opentracing.ErrSpanContextCorrupted
+		}
+		// Sampling decision only.
+		return c, nil
+	}
+	if c.TraceIDHigh, c.TraceID, err = parseTraceID(traceID); err != nil {
+		return Context{}, err
+	}
+	if c.SpanID, err = parseID(spanID); err != nil {
+		return Context{}, err
+	}
+	if parentID != "" {
+		if c.ParentSpanID, err = parseID(parentID); err != nil {
+			return Context{}, err
+		}
+	}
+	return c, nil
+}
+
+func parseSingle(s string) (Context, error) {
+	var c Context
+	var err error
+	parts := strings.Split(s, "-")
+	if len(parts) == 1 {
+		// Sampling decision only.
+		if c.Sampling, err = parseSamplingState(parts[0]); err != nil {
+			return Context{}, err
+		}
+		return c, nil
+	}
+	if len(parts) > 4 {
+		return Context{}, opentracing.ErrSpanContextCorrupted
+	}
+	if c.TraceIDHigh, c.TraceID, err = parseTraceID(parts[0]); err != nil {
+		return Context{}, err
+	}
+	if c.SpanID, err = parseID(parts[1]); err != nil {
+		return Context{}, err
+	}
+	if len(parts) > 2 {
+		if c.Sampling, err = parseSamplingState(parts[2]); err != nil {
+			return Context{}, err
+		}
+	}
+	if len(parts) > 3 {
+		if c.ParentSpanID, err = parseID(parts[3]); err != nil {
+			return Context{}, err
+		}
+	}
+	return c, nil
+}
+
+func parseSamplingState(s string) (SamplingState, error) {
+	switch s {
+	case "0":
+		return SamplingDeny, nil
+	case "1":
+		return SamplingAccept, nil
+	case "d":
+		return SamplingDebug, nil
+	}
+	return SamplingUnset, opentracing.ErrSpanContextCorrupted
+}
+
+func formatTraceID(c Context) string {
+	if c.TraceIDHigh != 0 {
+		return formatID(c.TraceIDHigh) + formatID(c.TraceID)
+	}
+	return formatID(c.TraceID)
+}
+
+// formatID formats id as exactly 16 lower-case hex digits.
+func formatID(id uint64) string {
+	const zeros = "0000000000000000"
+	s := strconv.FormatUint(id, 16)
+	return zeros[len(s):] + s
+}
+
+// parseTraceID accepts a 16 or 32 character lower-hex trace ID.
+func parseTraceID(s string) (high, low uint64, err error) {
+	switch len(s) {
+	case 16:
+		low, err = parseHex(s)
+	case 32:
+		if high, err = parseHex(s[:16]); err == nil {
+			low, err = parseHex(s[16:])
+		}
+	default:
+		err = opentracing.ErrSpanContextCorrupted
+	}
+	if err != nil || (high == 0 && low == 0) {
+		return 0, 0, opentracing.ErrSpanContextCorrupted
+	}
+	return high, low, nil
+}
+
+// parseID accepts a 16 character lower-hex span ID.
+func parseID(s string) (uint64, error) {
+	if len(s) != 16 {
+		return 0, opentracing.ErrSpanContextCorrupted
+	}
+	id, err := parseHex(s)
+	if err != nil || id == 0 {
+		return 0, opentracing.ErrSpanContextCorrupted
+	}
+	return id, nil
+}
+
+func parseHex(s string) (uint64, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return 0, opentracing.ErrSpanContextCorrupted
+		}
+	}
+	return strconv.ParseUint(s, 16, 64)
+}
@@ -0,0 +1,137 @@
+package b3
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+func TestInjectMulti(t *testing.T) {
+	h := http.Header{}
+	c := Context{
+		TraceIDHigh:  0x463ac35c9f6413ad,
+		TraceID:      0x48485a3953bb6124,
+		SpanID:       0xa2fb4a1d1a96d312,
+		ParentSpanID: 0x0020000000000001,
+		Sampling:     SamplingAccept,
+	}
+	require.NoError(t, InjectMulti(c, opentracing.HTTPHeadersCarrier(h)))
+	assert.Equal(t, "463ac35c9f6413ad48485a3953bb6124", h.Get("X-B3-TraceId"))
+	assert.Equal(t, "a2fb4a1d1a96d312", h.Get("X-B3-SpanId"))
+	assert.Equal(t, "0020000000000001", h.Get("X-B3-ParentSpanId"))
+	assert.Equal(t, "1", h.Get("X-B3-Sampled"))
+	assert.Empty(t, h.Get("X-B3-Flags"))
+
+	extracted, err := Extract(opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, c, extracted)
+
+	c.Sampling = SamplingDebug
+	c.TraceIDHigh = 0
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, InjectMulti(c, carrier))
+	assert.Equal(t, "48485a3953bb6124", carrier[TraceIDHeader])
+	assert.Equal(t, "1", carrier[FlagsHeader])
+	assert.NotContains(t, carrier, SampledHeader)
+
+	extracted, err = Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, c, extracted)
+	assert.True(t, extracted.Sampling.Sampled())
+}
+
+func TestInjectSingle(t *testing.T) {
+	tests := []struct {
+		c        Context
+		expected string
+	}{
+		{Context{TraceID: 1, SpanID: 2}, "0000000000000001-0000000000000002"},
+		{Context{TraceID: 1, SpanID: 2, Sampling: SamplingDeny}, "0000000000000001-0000000000000002-0"},
+		{Context{TraceIDHigh: 3, TraceID: 1, SpanID: 2, Sampling: SamplingDebug, ParentSpanID: 4},
+			"00000000000000030000000000000001-0000000000000002-d-0000000000000004"},
+		{Context{Sampling: SamplingDeny}, "0"},
+		{Context{Sampling: SamplingAccept}, "1"},
+	}
+	for _, test := range tests {
+		carrier := opentracing.TextMapCarrier{}
+		require.NoError(t, InjectSingle(test.c, carrier))
+		assert.Equal(t, test.expected, carrier[SingleHeader])
+
+		extracted, err := Extract(carrier)
+		require.NoError(t, err)
+		assert.Equal(t, test.c, extracted)
+	}
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, InjectSingle(Context{}, carrier))
+	assert.Empty(t, carrier)
+}
+
+func TestExtract_SinglePrecedence(t *testing.T) {
+	carrier := opentracing.TextMapCarrier{
+		"b3":           "0000000000000001-0000000000000002-1",
+		"x-b3-traceid": "0000000000000009",
+		"x-b3-spanid":  "0000000000000009",
+	}
+	c, err := Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), c.TraceID)
+	assert.Equal(t, uint64(2), c.SpanID)
+}
+
+func TestExtract_SamplingOnly(t *testing.T) {
+	c, err := Extract(opentracing.TextMapCarrier{"X-B3-Sampled": "0"})
+	require.NoError(t, err)
+	assert.False(t, c.IsValid())
+	assert.Equal(t, SamplingDeny, c.Sampling)
+
+	c, err = Extract(opentracing.TextMapCarrier{"X-B3-Flags": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, SamplingDebug, c.Sampling)
+}
+
+func TestExtract_Errors(t *testing.T) {
+	tests := []struct {
+		carrier opentracing.TextMapCarrier
+		err     error
+	}{
+		{opentracing.TextMapCarrier{}, opentracing.ErrSpanContextNotFound},
+		{opentracing.TextMapCarrier{"other": "x"}, opentracing.ErrSpanContextNotFound},
+		{opentracing.TextMapCarrier{"X-B3-TraceId": "0000000000000001"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"X-B3-SpanId": "0000000000000001"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"X-B3-ParentSpanId": "0000000000000001"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			"X-B3-TraceId": "000000000000001", "X-B3-SpanId": "0000000000000001",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			"X-B3-TraceId": "000000000000000G", "X-B3-SpanId": "0000000000000001",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			"X-B3-TraceId": "0000000000000000", "X-B3-SpanId": "0000000000000001",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{
+			"X-B3-TraceId": "0000000000000001", "X-B3-SpanId": "0000000000000001", "X-B3-Sampled": "yes",
+		}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"X-B3-Flags": "2"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"b3": "x"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"b3": "0000000000000001"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"b3": "0000000000000001-0000000000000002-2"}, opentracing.ErrSpanContextCorrupted},
+		{opentracing.TextMapCarrier{"b3": "0000000000000001-0000000000000002-1-0000000000000003-x"}, opentracing.ErrSpanContextCorrupted},
+	}
+	for _, test := range tests {
+		_, err := Extract(test.carrier)
+		assert.Equal(t, test.err, err, "%v", test.carrier)
+	}
+}
+
+func TestExtract_LegacySampled(t *testing.T) {
+	c, err := Extract(opentracing.TextMapCarrier{
+		"X-B3-TraceId": "0000000000000001", "X-B3-SpanId": "0000000000000002", "X-B3-Sampled": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SamplingAccept, c.Sampling)
+}
@@ -0,0 +1,275 @@
+// Package baggage implements the W3C Baggage propagation format
+// (https://www.w3.org/TR/baggage/), i.e. the `baggage` header.
+//
+// Like package tracecontext, this is a codec rather than a Tracer. It reads
+// and writes the header through opentracing.TextMapReader/TextMapWriter, so
+// it works with both opentracing.TextMapCarrier and
+// opentracing.HTTPHeadersCarrier. A tracer typically injects the baggage of
+// any SpanContext with
+//
+//     err := baggage.Inject(baggage.FromSpanContext(sc), carrier)
+//
+// and, on extraction, copies baggage.Baggage.Map() into its own SpanContext.
+package baggage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// HeaderName is the name of the header holding the Baggage.
+	HeaderName = "baggage"
+
+	// MaxMembers is the maximum number of list members propagated.
+	MaxMembers = 64
+
+	// MaxBytes is the maximum size in bytes of a serialized `baggage`
+	// header.
+	MaxBytes = 8192
+)
+
+// Property is an optional key or key=value attribute attached to a Member.
+type Property struct {
+	Key   string
+	Value string
+	// HasValue distinguishes "key=" (an empty value) from a bare "key".
+	HasValue bool
+}
+
+// Member is a single baggage entry together with its properties. Value is
+// always stored decoded; percent-encoding is applied on serialization.
+type Member struct {
+	Key        string
+	Value      string
+	Properties []Property
+}
+
+// Baggage is an ordered list of baggage Members. The zero value is empty.
+type Baggage struct {
+	members []Member
+}
+
+// New builds a Baggage from `members`. It returns
+// opentracing.ErrSpanContextCorrupted if a key is not a valid token.
+func New(members ...Member) (Baggage, error) {
+	for _, m := range members {
+		if !isToken(m.Key) {
+			return Baggage{}, opentracing.ErrSpanContextCorrupted
+		}
+		for _, p := range m.Properties {
+			if !isToken(p.Key) {
+				return Baggage{}, opentracing.ErrSpanContextCorrupted
+			}
+		}
+	}
+	return Baggage{members: append([]Member(nil), members...)}, nil
+}
+
+// FromSpanContext collects the baggage items of `sc`, sorted by key. Items
+// whose key is not a valid HTTP token can't be represented in the header
+// and are skipped.
+func FromSpanContext(sc opentracing.SpanContext) Baggage {
+	var members []Member
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		if isToken(k) {
+			members = append(members, Member{Key: k, Value: v})
+		}
+		return true
+	})
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Key < members[j].Key
+	})
+	return Baggage{members: members}
+}
+
+// Len returns the number of members.
+func (b Baggage) Len() int {
+	return len(b.members)
+}
+
+// Members returns a copy of the members, in header order.
+func (b Baggage) Members() []Member {
+	return append([]Member(nil), b.members...)
+}
+
+// Get returns the value for `key`, or "" if it is not present. If the key is
+// repeated, the last value wins.
+func (b Baggage) Get(key string) string {
+	val := ""
+	for _, m := range b.members {
+		if m.Key == key {
+			val = m.Value
+		}
+	}
+	return val
+}
+
+// Map returns the members as a key:value map, dropping properties. If a key
+// is repeated, the last value wins. Map returns nil for an empty Baggage.
+func (b Baggage) Map() map[string]string {
+	if len(b.members) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(b.members))
+	for _, member := range b.members {
+		m[member.Key] = member.Value
+	}
+	return m
+}
+
+// String serializes the Baggage as a `baggage` header value. Members are
+// written in order; once MaxMembers is reached, or once adding a member
+// would exceed MaxBytes, the remaining members that don't fit are dropped.
+func (b Baggage) String() string {
+	var sb strings.Builder
+	written := 0
+	for _, m := range b.members {
+		if written == MaxMembers {
+			break
+		}
+		encoded := encodeMember(m)
+		size := len(encoded)
+		if written > 0 {
+			size++
+		}
+		if sb.Len()+size > MaxBytes {
+			continue
+		}
+		if written > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(encoded)
+		written++
+	}
+	return sb.String()
+}
+
+func encodeMember(m Member) string {
+	var sb strings.Builder
+	sb.WriteString(m.Key)
+	sb.WriteByte('=')
+	sb.WriteString(encodeValue(m.Value))
+	for _, p := range m.Properties {
+		sb.WriteByte(';')
+		sb.WriteString(p.Key)
+		if p.HasValue {
+			sb.WriteByte('=')
+			sb.WriteString(encodeValue(p.Value))
+		}
+	}
+	return sb.String()
+}
+
+// Parse parses a `baggage` header value. It returns
+// opentracing.ErrSpanContextCorrupted if the value is malformed or larger
+// than MaxBytes. Members beyond MaxMembers are dropped.
+func Parse(s string) (Baggage, error) {
+	if len(s) > MaxBytes {
+		return Baggage{}, opentracing.ErrSpanContextCorrupted
+	}
+	var members []Member
+	for _, entry := range strings.Split(s, ",") {
+		entry = trimOWS(entry)
+		if entry == "" {
+			continue
+		}
+		m, err := parseMember(entry)
+		if err != nil {
+			return Baggage{}, err
+		}
+		if len(members) < MaxMembers {
+			members = append(members, m)
+		}
+	}
+	return Baggage{members: members}, nil
+}
+
+func parseMember(s string) (Member, error) {
+	parts := strings.Split(s, ";")
+	key, value, hasValue, err := parseKeyValue(parts[0])
+	if err != nil || !hasValue {
+		return Member{}, opentracing.ErrSpanContextCorrupted
+	}
+	m := Member{Key: key, Value: value}
+	for _, part := range parts[1:] {
+		pKey, pValue, pHasValue, err := parseKeyValue(part)
+		if err != nil {
+			return Member{}, err
+		}
+		m.Properties = append(m.Properties, Property{Key: pKey, Value: pValue, HasValue: pHasValue})
+	}
+	return m, nil
+}
+
+func parseKeyValue(s string) (key, value string, hasValue bool, err error) {
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		key = trimOWS(s)
+	} else {
+		key = trimOWS(s[:eq])
+		if value, err = decodeValue(trimOWS(s[eq+1:])); err != nil {
+			return "", "", false, err
+		}
+		hasValue = true
+	}
+	if !isToken(key) {
+		return "", "", false, opentracing.ErrSpanContextCorrupted
+	}
+	return key, value, hasValue, nil
+}
+
+// Inject writes `b` to `carrier` as a `baggage` entry. Nothing is written if
+// `b` is empty.
+func Inject(b Baggage, carrier opentracing.TextMapWriter) error {
+	if encoded := b.String(); encoded != "" {
+		carrier.Set(HeaderName, encoded)
+	}
+	return nil
+}
+
+// Extract reads Baggage from `carrier`. Header names are matched
+// case-insensitively, and multiple `baggage` entries are combined in order.
+//
+// Extract returns opentracing.ErrSpanContextNotFound if there is no
+// `baggage` entry, and opentracing.ErrSpanContextCorrupted if it is
+// malformed.
+func Extract(carrier opentracing.TextMapReader) (Baggage, error) {
+	var headers []string
+	err := carrier.ForeachKey(func(key, val string) error {
+		if strings.EqualFold(key, HeaderName) {
+			headers = append(headers, val)
+		}
+		return nil
+	})
+	if err != nil {
+		return Baggage{}, err
+	}
+	if len(headers) == 0 {
+		return Baggage{}, opentracing.ErrSpanContextNotFound
+	}
+	return Parse(strings.Join(headers, ","))
+}
+
+func trimOWS(s string) string {
+	return strings.Trim(s, " \t")
+}
+
+// isToken reports whether s is a non-empty RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package baggage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestParse(t *testing.T) {
+	b, err := Parse(" userId = alice%20smith ,serverNode=DF%2028;prop1;prop2 = v%2C2 ,, isProduction=false")
+	require.NoError(t, err)
+	assert.Equal(t, []Member{
+		{Key: "userId", Value: "alice smith"},
+		{Key: "serverNode", Value: "DF 28", Properties: []Property{
+			{Key: "prop1"},
+			{Key: "prop2", Value: "v,2", HasValue: true},
+		}},
+		{Key: "isProduction", Value: "false"},
+	}, b.Members())
+	assert.Equal(t, "alice smith", b.Get("userId"))
+	assert.Equal(t, map[string]string{
+		"userId":       "alice smith",
+		"serverNode":   "DF 28",
+		"isProduction": "false",
+	}, b.Map())
+	assert.Equal(t, "userId=alice%20smith,serverNode=DF%2028;prop1;prop2=v%2C2,isProduction=false", b.String())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"novalue",
+		"=value",
+		"bad key=value",
+		"key=val ue",
+		"key=\"quoted\"",
+		"key=back\\slash",
+		"key=%zz",
+		"key=%4",
+		"key=value;bad prop",
+		strings.Repeat("k=v,", MaxBytes/4+1),
+	}
+	for _, test := range tests {
+		_, err := Parse(test)
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, test)
+	}
+}
+
+func TestParse_MaxMembers(t *testing.T) {
+	entries := make([]string, MaxMembers+10)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("k%d=v", i)
+	}
+	b, err := Parse(strings.Join(entries, ","))
+	require.NoError(t, err)
+	assert.Equal(t, MaxMembers, b.Len())
+	assert.Equal(t, "", b.Get(fmt.Sprintf("k%d", MaxMembers)))
+}
+
+func TestString_Limits(t *testing.T) {
+	members := make([]Member, MaxMembers+1)
+	for i := range members {
+		members[i] = Member{Key: fmt.Sprintf("k%d", i), Value: "v"}
+	}
+	b, err := New(members...)
+	require.NoError(t, err)
+	parsed, err := Parse(b.String())
+	require.NoError(t, err)
+	assert.Equal(t, MaxMembers, parsed.Len())
+
+	big := strings.Repeat("x", MaxBytes-10)
+	b, err = New(
+		Member{Key: "big", Value: big},
+		Member{Key: "toolarge", Value: "0123456789"},
+		Member{Key: "s", Value: "1"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "big="+big+",s=1", b.String())
+	assert.True(t, len(b.String()) <= MaxBytes)
+
+	_, err = New(Member{Key: "bad key", Value: "v"})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+}
+
+func TestEncodeValue_RoundTrip(t *testing.T) {
+	values := []string{"", "plain", "a b", "100%", "日本語", "a,b;c=d\"e\\f", "\x00\x7f"}
+	for _, v := range values {
+		decoded, err := decodeValue(encodeValue(v))
+		require.NoError(t, err, v)
+		assert.Equal(t, v, decoded)
+	}
+}
+
+func TestInjectExtract_SpanContext(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("x")
+	span.SetBaggageItem("user", "alice smith")
+	span.SetBaggageItem("tenant", "a,b")
+	span.SetBaggageItem("not a token", "skipped")
+
+	carrier := opentracing.HTTPHeadersCarrier(http.Header{})
+	require.NoError(t, Inject(FromSpanContext(span.Context()), carrier))
+	assert.Equal(t, "tenant=a%2Cb,user=alice%20smith", http.Header(carrier).Get("baggage"))
+
+	b, err := Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "alice smith", "tenant": "a,b"}, b.Map())
+}
+
+func TestExtract(t *testing.T) {
+	_, err := Extract(opentracing.TextMapCarrier{"other": "x"})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+	_, err = Extract(opentracing.TextMapCarrier{"baggage": "bad key=1"})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+
+	h := http.Header{}
+	h.Add("Baggage", "a=1")
+	h.Add("Baggage", "b=2")
+	b, err := Extract(opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, "a=1,b=2", b.String())
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, Inject(Baggage{}, carrier))
+	assert.Empty(t, carrier)
+}
@@ -0,0 +1,97 @@
+package baggage
+
+import (
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const upperHex = "0123456789ABCDEF"
+
+// isBaggageOctet reports whether c may appear unescaped in a value:
+//
+//     baggage-octet = %x21 / %x23-2B / %x2D-3A / %x3C-5B / %x5D-7E
+//
+// '%' is a baggage-octet, but it is always escaped on output so that
+// decoding is unambiguous.
+func isBaggageOctet(c byte) bool {
+	return c == 0x21 ||
+		(0x23 <= c && c <= 0x2b && c != '%') ||
+		(0x2d <= c && c <= 0x3a) ||
+		(0x3c <= c && c <= 0x5b) ||
+		(0x5d <= c && c <= 0x7e)
+}
+
+// encodeValue percent-encodes every byte of the UTF-8 value that is not a
+// baggage-octet.
+func encodeValue(s string) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if !isBaggageOctet(s[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) + 2*n)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isBaggageOctet(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(upperHex[c>>4])
+		sb.WriteByte(upperHex[c&0xf])
+	}
+	return sb.String()
+}
+
+// decodeValue validates an encoded value and reverses its percent-encoding.
+func decodeValue(s string) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		for i := 0; i < len(s); i++ {
+			if !isBaggageOctet(s[i]) {
+				return "", opentracing.ErrSpanContextCorrupted
+			}
+		}
+		return s, nil
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(s) {
+				return "", opentracing.ErrSpanContextCorrupted
+			}
+			hi, ok1 := unhex(s[i+1])
+			lo, ok2 := unhex(s[i+2])
+			if !ok1 || !ok2 {
+				return "", opentracing.ErrSpanContextCorrupted
+			}
+			sb.WriteByte(hi<<4 | lo)
+			i += 2
+		case isBaggageOctet(c):
+			sb.WriteByte(c)
+		default:
+			return "", opentracing.ErrSpanContextCorrupted
+		}
+	}
+	return sb.String(), nil
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package propagation
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// NamedPropagator pairs a Propagator with a name that CompositePropagator
+// reports when the Propagator's Extract succeeds.
+type NamedPropagator struct {
+	Name       string
+	Propagator Propagator
+}
+
+// CompositePropagator injects a SpanContext with every one of its
+// Propagators, and extracts with the first one that succeeds. It is meant
+// for migrations between header conventions, where a service must emit and
+// accept several formats at once:
+//
+//     composite := propagation.NewCompositePropagator(
+//         propagation.NamedPropagator{Name: "w3c", Propagator: w3cPropagator},
+//         propagation.NamedPropagator{Name: "b3", Propagator: b3Propagator},
+//     )
+//     composite.OnExtract = func(name string) { extractedBy.WithLabel(name).Inc() }
+//
+// Since all Propagators write to the same carrier, it is intended for
+// TextMap and HTTPHeaders carriers.
+type CompositePropagator struct {
+	propagators []NamedPropagator
+
+	// OnExtract, if set, is called with the name of the Propagator whose
+	// Extract succeeded. It must be set before the CompositePropagator is
+	// used, and may be called concurrently.
+	OnExtract func(name string)
+}
+
+// NewCompositePropagator returns a CompositePropagator. Extraction is
+// attempted in the order the propagators are given.
+func NewCompositePropagator(propagators ...NamedPropagator) *CompositePropagator {
+	return &CompositePropagator{
+		propagators: append([]NamedPropagator(nil), propagators...),
+	}
+}
+
+// Inject calls Inject on every Propagator. All Propagators are attempted
+// even if one fails; the first error is returned.
+func (c *CompositePropagator) Inject(sc opentracing.SpanContext, carrier interface{}) error {
+	var firstErr error
+	for _, p := range c.propagators {
+		if err := p.Propagator.Inject(sc, carrier); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Extract belongs to the Extractor interface. See ExtractNamed.
+func (c *CompositePropagator) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	sc, _, err := c.ExtractNamed(carrier)
+	return sc, err
+}
+
+// ExtractNamed tries each Propagator in order and returns the SpanContext
+// from the first one that succeeds, along with that Propagator's name.
+//
+// If none succeeds, it returns opentracing.ErrSpanContextNotFound when every
+// Propagator reported that error, and otherwise the first other error (for
+// instance opentracing.ErrSpanContextCorrupted).
+func (c *CompositePropagator) ExtractNamed(carrier interface{}) (opentracing.SpanContext, string, error) {
+	var firstErr error
+	for _, p := range c.propagators {
+		sc, err := p.Propagator.Extract(carrier)
+		if err == nil {
+			if c.OnExtract != nil {
+				c.OnExtract(p.Name)
+			}
+			return sc, p.Name, nil
+		}
+		if err != opentracing.ErrSpanContextNotFound && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = opentracing.ErrSpanContextNotFound
+	}
+	return nil, "", firstErr
+}
@@ -0,0 +1,145 @@
+package propagation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+type testSpanContext struct {
+	id string
+}
+
+func (testSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+// keyPropagator stores the context under a single TextMap key.
+type keyPropagator struct {
+	key string
+}
+
+func (p keyPropagator) Inject(sc opentracing.SpanContext, carrier interface{}) error {
+	tsc, ok := sc.(testSpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	writer.Set(p.key, tsc.id)
+	return nil
+}
+
+func (p keyPropagator) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+	var sc opentracing.SpanContext
+	err := reader.ForeachKey(func(key, val string) error {
+		if key == p.key {
+			if val == "" {
+				return opentracing.ErrSpanContextCorrupted
+			}
+			sc = testSpanContext{val}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sc == nil {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	return sc, nil
+}
+
+func newTestComposite() *CompositePropagator {
+	return NewCompositePropagator(
+		NamedPropagator{Name: "new", Propagator: keyPropagator{"new-id"}},
+		NamedPropagator{Name: "old", Propagator: keyPropagator{"old-id"}},
+	)
+}
+
+func TestCompositePropagator_Inject(t *testing.T) {
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, newTestComposite().Inject(testSpanContext{"42"}, carrier))
+	assert.Equal(t, opentracing.TextMapCarrier{"new-id": "42", "old-id": "42"}, carrier)
+
+	err := newTestComposite().Inject(testSpanContext{"42"}, "not a carrier")
+	assert.Equal(t, opentracing.ErrInvalidCarrier, err)
+}
+
+func TestCompositePropagator_ExtractPriority(t *testing.T) {
+	var matched []string
+	composite := newTestComposite()
+	composite.OnExtract = func(name string) { matched = append(matched, name) }
+
+	sc, name, err := composite.ExtractNamed(opentracing.TextMapCarrier{"new-id": "1", "old-id": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, testSpanContext{"1"}, sc)
+	assert.Equal(t, "new", name)
+
+	sc, err = composite.Extract(opentracing.TextMapCarrier{"old-id": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, testSpanContext{"2"}, sc)
+
+	// A corrupted higher-priority format doesn't prevent a fallback.
+	sc, name, err = composite.ExtractNamed(opentracing.TextMapCarrier{"new-id": "", "old-id": "3"})
+	require.NoError(t, err)
+	assert.Equal(t, testSpanContext{"3"}, sc)
+	assert.Equal(t, "old", name)
+
+	assert.Equal(t, []string{"new", "old", "old"}, matched)
+}
+
+func TestCompositePropagator_ExtractErrors(t *testing.T) {
+	composite := newTestComposite()
+	sc, name, err := composite.ExtractNamed(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	assert.Nil(t, sc)
+	assert.Equal(t, "", name)
+
+	_, err = composite.Extract(opentracing.TextMapCarrier{"old-id": ""})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+
+	_, err = NewCompositePropagator().Extract(opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(opentracing.TextMap, newTestComposite())
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, registry.Inject(testSpanContext{"7"}, opentracing.TextMap, carrier))
+	sc, err := registry.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, testSpanContext{"7"}, sc)
+
+	sc, err = registry.Extract(opentracing.TextMap, opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	assert.Nil(t, sc)
+
+	assert.Equal(t, opentracing.ErrUnsupportedFormat,
+		registry.Inject(testSpanContext{"7"}, opentracing.Binary, carrier))
+	_, err = registry.Extract(opentracing.Binary, carrier)
+	assert.Equal(t, opentracing.ErrUnsupportedFormat, err)
+
+	customErr := errors.New("custom")
+	registry.RegisterExtractor("custom", extractorFunc(func(interface{}) (opentracing.SpanContext, error) {
+		return nil, customErr
+	}))
+	_, err = registry.Extract("custom", carrier)
+	assert.Equal(t, customErr, err)
+}
+
+type extractorFunc func(carrier interface{}) (opentracing.SpanContext, error)
+
+func (f extractorFunc) Extract(carrier interface{}) (opentracing.SpanContext, error) {
+	return f(carrier)
+}
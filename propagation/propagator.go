@@ -0,0 +1,101 @@
+// Package propagation provides tracer-independent building blocks for
+// Tracer.Inject and Tracer.Extract: the Propagator interface, a Registry
+// that maps formats to Propagators, and a CompositePropagator that speaks
+// several wire formats at once.
+//
+// Codecs for specific wire formats live in the subpackages b3, baggage and
+// tracecontext.
+package propagation
+
+import (
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Injector injects a SpanContext into a format-specific carrier. It is the
+// tracer-independent counterpart of Tracer.Inject for a single format.
+type Injector interface {
+	// Inject takes `sc` and injects it into `carrier`. Implementations
+	// return opentracing.ErrInvalidSpanContext if `sc` was created by a
+	// tracer they don't understand, and opentracing.ErrInvalidCarrier if
+	// `carrier` has the wrong type.
+	Inject(sc opentracing.SpanContext, carrier interface{}) error
+}
+
+// Extractor extracts a SpanContext from a format-specific carrier. It is
+// the tracer-independent counterpart of Tracer.Extract for a single format.
+type Extractor interface {
+	// Extract decodes a SpanContext from `carrier`, or returns
+	// (nil, opentracing.ErrSpanContextNotFound) if there is none.
+	Extract(carrier interface{}) (opentracing.SpanContext, error)
+}
+
+// Propagator is both an Injector and an Extractor.
+type Propagator interface {
+	Injector
+	Extractor
+}
+
+// Registry maps `format` values to Injectors and Extractors. Tracer
+// implementations can embed one and forward Tracer.Inject and
+// Tracer.Extract to it. A Registry is safe for concurrent use.
+type Registry struct {
+	lock       sync.RWMutex
+	injectors  map[interface{}]Injector
+	extractors map[interface{}]Extractor
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		injectors:  make(map[interface{}]Injector),
+		extractors: make(map[interface{}]Extractor),
+	}
+}
+
+// RegisterInjector registers injector for given format
+func (r *Registry) RegisterInjector(format interface{}, injector Injector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.injectors[format] = injector
+}
+
+// RegisterExtractor registers extractor for given format
+func (r *Registry) RegisterExtractor(format interface{}, extractor Extractor) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.extractors[format] = extractor
+}
+
+// Register registers propagator as both injector and extractor for format.
+func (r *Registry) Register(format interface{}, propagator Propagator) {
+	r.RegisterInjector(format, propagator)
+	r.RegisterExtractor(format, propagator)
+}
+
+// Inject has the semantics of Tracer.Inject.
+func (r *Registry) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	r.lock.RLock()
+	injector, ok := r.injectors[format]
+	r.lock.RUnlock()
+	if !ok {
+		return opentracing.ErrUnsupportedFormat
+	}
+	return injector.Inject(sc, carrier)
+}
+
+// Extract has the semantics of Tracer.Extract.
+func (r *Registry) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	r.lock.RLock()
+	extractor, ok := r.extractors[format]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	sc, err := extractor.Extract(carrier)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
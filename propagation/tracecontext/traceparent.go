@@ -0,0 +1,261 @@
+// Package tracecontext implements the W3C Trace Context propagation format
+// (https://www.w3.org/TR/trace-context/), i.e. the `traceparent` and
+// `tracestate` headers.
+//
+// The package is a codec rather than a Tracer: it converts between the
+// headers found in an opentracing.TextMapReader/TextMapWriter and the
+// TraceContext struct, and leaves the mapping to and from a concrete
+// opentracing.SpanContext to the tracer implementation. A tracer typically
+// calls Extract from its Tracer.Extract for the HTTPHeaders and TextMap
+// formats:
+//
+//     tc, err := tracecontext.Extract(reader)
+//     if err != nil {
+//         return nil, err
+//     }
+//     return mySpanContext{
+//         TraceID: tc.TraceParent.TraceID,
+//         ...
+//     }, nil
+package tracecontext
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// TraceParentHeader is the name of the header holding the TraceParent.
+	TraceParentHeader = "traceparent"
+
+	// TraceStateHeader is the name of the header holding the TraceState.
+	TraceStateHeader = "tracestate"
+
+	// SupportedVersion is the traceparent version written by this package.
+	SupportedVersion byte = 0
+
+	// maxVersion is the forbidden "ff" version.
+	maxVersion byte = 0xff
+
+	// traceParentLen is the length of a version 00 traceparent value:
+	// "00-" + 32 hex + "-" + 16 hex + "-" + 2 hex.
+	traceParentLen = 55
+)
+
+// TraceID is a 16-byte W3C trace ID.
+type TraceID [16]byte
+
+// NewTraceID builds a TraceID from its high and low 64-bit halves. A 64-bit
+// trace ID is represented with high == 0.
+func NewTraceID(high, low uint64) TraceID {
+	var t TraceID
+	binary.BigEndian.PutUint64(t[:8], high)
+	binary.BigEndian.PutUint64(t[8:], low)
+	return t
+}
+
+// High returns the upper 64 bits of the trace ID.
+func (t TraceID) High() uint64 {
+	return binary.BigEndian.Uint64(t[:8])
+}
+
+// Low returns the lower 64 bits of the trace ID.
+func (t TraceID) Low() uint64 {
+	return binary.BigEndian.Uint64(t[8:])
+}
+
+// IsValid reports whether the trace ID is not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String returns the trace ID as 32 lower-case hex digits.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is an 8-byte W3C parent (span) ID.
+type SpanID [8]byte
+
+// NewSpanID builds a SpanID from a 64-bit integer.
+func NewSpanID(id uint64) SpanID {
+	var s SpanID
+	binary.BigEndian.PutUint64(s[:], id)
+	return s
+}
+
+// Uint64 returns the span ID as a 64-bit integer.
+func (s SpanID) Uint64() uint64 {
+	return binary.BigEndian.Uint64(s[:])
+}
+
+// IsValid reports whether the span ID is not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String returns the span ID as 16 lower-case hex digits.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// Flags holds the trace-flags field of a traceparent.
+type Flags byte
+
+// FlagsSampled is the only trace flag defined by version 00 of the spec.
+const FlagsSampled Flags = 0x01
+
+// Sampled reports whether the sampled flag is set.
+func (f Flags) Sampled() bool {
+	return f&FlagsSampled != 0
+}
+
+// TraceParent is the parsed form of a `traceparent` header.
+type TraceParent struct {
+	Version byte
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   Flags
+}
+
+// String serializes the TraceParent as a version 00 `traceparent` value.
+// Flags not defined by version 00 are cleared, as required by the spec.
+func (tp TraceParent) String() string {
+	var b [traceParentLen]byte
+	hex.Encode(b[0:2], []byte{SupportedVersion})
+	b[2] = '-'
+	hex.Encode(b[3:35], tp.TraceID[:])
+	b[35] = '-'
+	hex.Encode(b[36:52], tp.SpanID[:])
+	b[52] = '-'
+	hex.Encode(b[53:55], []byte{byte(tp.Flags & FlagsSampled)})
+	return string(b[:])
+}
+
+// ParseTraceParent parses a `traceparent` header value. It returns
+// opentracing.ErrSpanContextCorrupted if the value does not conform to the
+// spec.
+//
+// Values with a version higher than 00 are accepted as long as their
+// version 00 prefix is valid, per the spec's forward-compatibility rules.
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+	if len(s) < traceParentLen {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	var version [1]byte
+	if !decodeLowerHex(version[:], s[0:2]) {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	tp.Version = version[0]
+	switch {
+	case tp.Version == maxVersion:
+		return tp, opentracing.ErrSpanContextCorrupted
+	case tp.Version == SupportedVersion && len(s) != traceParentLen:
+		return tp, opentracing.ErrSpanContextCorrupted
+	case len(s) > traceParentLen && s[traceParentLen] != '-':
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	if !decodeLowerHex(tp.TraceID[:], s[3:35]) ||
+		!decodeLowerHex(tp.SpanID[:], s[36:52]) {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	var flags [1]byte
+	if !decodeLowerHex(flags[:], s[53:55]) {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	tp.Flags = Flags(flags[0])
+	if !tp.TraceID.IsValid() || !tp.SpanID.IsValid() {
+		return tp, opentracing.ErrSpanContextCorrupted
+	}
+	return tp, nil
+}
+
+// decodeLowerHex decodes src into dst, accepting only lower-case hex digits
+// as required by the spec.
+func decodeLowerHex(dst []byte, src string) bool {
+	if len(src) != 2*len(dst) {
+		return false
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// TraceContext is the combination of a TraceParent and its TraceState.
+type TraceContext struct {
+	TraceParent TraceParent
+	TraceState  TraceState
+}
+
+// Inject writes `tc` to `carrier` as `traceparent` and, if non-empty,
+// `tracestate` entries.
+func Inject(tc TraceContext, carrier opentracing.TextMapWriter) error {
+	if !tc.TraceParent.TraceID.IsValid() || !tc.TraceParent.SpanID.IsValid() {
+		return opentracing.ErrInvalidSpanContext
+	}
+	carrier.Set(TraceParentHeader, tc.TraceParent.String())
+	if tc.TraceState.Len() > 0 {
+		carrier.Set(TraceStateHeader, tc.TraceState.String())
+	}
+	return nil
+}
+
+// Extract reads a TraceContext from `carrier`. Header names are matched
+// case-insensitively, so it works with both opentracing.TextMapCarrier and
+// opentracing.HTTPHeadersCarrier.
+//
+// Extract returns opentracing.ErrSpanContextNotFound if there is no
+// `traceparent`, and opentracing.ErrSpanContextCorrupted if it is malformed
+// or repeated. As the spec requires, a malformed `tracestate` does not
+// invalidate the `traceparent`; it is discarded and an empty TraceState is
+// returned instead. Multiple `tracestate` entries are combined in order.
+func Extract(carrier opentracing.TextMapReader) (TraceContext, error) {
+	var (
+		traceParent    string
+		traceStates    []string
+		numTraceParent int
+	)
+	err := carrier.ForeachKey(func(key, val string) error {
+		switch strings.ToLower(key) {
+		case TraceParentHeader:
+			traceParent = val
+			numTraceParent++
+		case TraceStateHeader:
+			traceStates = append(traceStates, val)
+		}
+		return nil
+	})
+	if err != nil {
+		return TraceContext{}, err
+	}
+	switch numTraceParent {
+	case 0:
+		return TraceContext{}, opentracing.ErrSpanContextNotFound
+	case 1:
+	default:
+		return TraceContext{}, opentracing.ErrSpanContextCorrupted
+	}
+	tp, err := ParseTraceParent(strings.TrimSpace(traceParent))
+	if err != nil {
+		return TraceContext{}, err
+	}
+	tc := TraceContext{TraceParent: tp}
+	if len(traceStates) > 0 {
+		if ts, err := ParseTraceState(strings.Join(traceStates, ",")); err == nil {
+			tc.TraceState = ts
+		}
+	}
+	return tc, nil
+}
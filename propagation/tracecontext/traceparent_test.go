@@ -0,0 +1,115 @@
+package tracecontext
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const validTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tp, err := ParseTraceParent(validTraceParent)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0), tp.Version)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tp.TraceID.String())
+	assert.Equal(t, uint64(0x4bf92f3577b34da6), tp.TraceID.High())
+	assert.Equal(t, uint64(0xa3ce929d0e0e4736), tp.TraceID.Low())
+	assert.Equal(t, "00f067aa0ba902b7", tp.SpanID.String())
+	assert.Equal(t, uint64(0x00f067aa0ba902b7), tp.SpanID.Uint64())
+	assert.True(t, tp.Flags.Sampled())
+	assert.Equal(t, validTraceParent, tp.String())
+}
+
+func TestParseTraceParent_FutureVersion(t *testing.T) {
+	tp, err := ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-what-the-future-holds")
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xcc), tp.Version)
+	// Unknown flags are cleared and the version is downgraded on output.
+	assert.Equal(t, validTraceParent, tp.String())
+}
+
+func TestParseTraceParent_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"0g-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+		"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.extra",
+	}
+	for _, test := range tests {
+		_, err := ParseTraceParent(test)
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, test)
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	ts, err := ParseTraceState("congo=t61rcWkgMzE,rojo=00f067aa0ba902b7")
+	require.NoError(t, err)
+	tc := TraceContext{
+		TraceParent: TraceParent{
+			TraceID: NewTraceID(1, 2),
+			SpanID:  NewSpanID(3),
+			Flags:   FlagsSampled,
+		},
+		TraceState: ts,
+	}
+
+	carriers := []interface {
+		opentracing.TextMapReader
+		opentracing.TextMapWriter
+	}{
+		opentracing.TextMapCarrier{},
+		opentracing.HTTPHeadersCarrier(http.Header{}),
+	}
+	for _, carrier := range carriers {
+		require.NoError(t, Inject(tc, carrier))
+		extracted, err := Extract(carrier)
+		require.NoError(t, err)
+		assert.Equal(t, tc, extracted)
+	}
+
+	assert.Equal(t, opentracing.ErrInvalidSpanContext,
+		Inject(TraceContext{}, opentracing.TextMapCarrier{}))
+}
+
+func TestExtract_Errors(t *testing.T) {
+	_, err := Extract(opentracing.TextMapCarrier{"unrelated": "x"})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+	_, err = Extract(opentracing.TextMapCarrier{"traceparent": "garbage"})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+
+	h := http.Header{}
+	h.Add("traceparent", validTraceParent)
+	h.Add("traceparent", validTraceParent)
+	_, err = Extract(opentracing.HTTPHeadersCarrier(h))
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+}
+
+func TestExtract_TraceState(t *testing.T) {
+	h := http.Header{}
+	h.Set("traceparent", validTraceParent)
+	h.Add("tracestate", "rojo=00f067aa0ba902b7")
+	h.Add("tracestate", "congo=t61rcWkgMzE")
+	tc, err := Extract(opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE", tc.TraceState.String())
+
+	// An invalid tracestate is discarded without invalidating traceparent.
+	h.Set("tracestate", "BAD KEY=1")
+	tc, err = Extract(opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, 0, tc.TraceState.Len())
+	assert.Equal(t, validTraceParent, tc.TraceParent.String())
+}
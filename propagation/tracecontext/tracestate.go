@@ -0,0 +1,192 @@
+package tracecontext
+
+import (
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// MaxTraceStateMembers is the maximum number of list members allowed in
+	// a `tracestate` header.
+	MaxTraceStateMembers = 32
+
+	maxKeyLen      = 256
+	maxTenantIDLen = 241
+	maxSystemIDLen = 14
+	maxValueLen    = 256
+)
+
+// TraceStateMember is a single key=value entry of a `tracestate` header.
+type TraceStateMember struct {
+	Key   string
+	Value string
+}
+
+// TraceState is the parsed form of a `tracestate` header: an ordered list of
+// vendor-specific key=value pairs, most recently updated first.
+//
+// TraceState values are immutable; Set and Delete return modified copies so
+// that a TraceState extracted from a parent can be shared by its children.
+// The zero value is an empty TraceState.
+type TraceState struct {
+	members []TraceStateMember
+}
+
+// NewTraceState builds a TraceState from `members`, validating every key and
+// value and rejecting duplicate keys.
+func NewTraceState(members ...TraceStateMember) (TraceState, error) {
+	if len(members) > MaxTraceStateMembers {
+		return TraceState{}, opentracing.ErrSpanContextCorrupted
+	}
+	seen := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		if !validKey(m.Key) || !validValue(m.Value) {
+			return TraceState{}, opentracing.ErrSpanContextCorrupted
+		}
+		if _, ok := seen[m.Key]; ok {
+			return TraceState{}, opentracing.ErrSpanContextCorrupted
+		}
+		seen[m.Key] = struct{}{}
+	}
+	return TraceState{members: append([]TraceStateMember(nil), members...)}, nil
+}
+
+// ParseTraceState parses a `tracestate` header value. Empty list members and
+// optional whitespace around members are allowed, as in the spec. It
+// returns opentracing.ErrSpanContextCorrupted if any member is malformed,
+// if a key is repeated, or if there are more than MaxTraceStateMembers.
+func ParseTraceState(s string) (TraceState, error) {
+	var members []TraceStateMember
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.Trim(entry, " \t")
+		if entry == "" {
+			continue
+		}
+		eq := strings.IndexByte(entry, '=')
+		if eq < 0 {
+			return TraceState{}, opentracing.ErrSpanContextCorrupted
+		}
+		members = append(members, TraceStateMember{Key: entry[:eq], Value: entry[eq+1:]})
+	}
+	return NewTraceState(members...)
+}
+
+// String serializes the TraceState as a `tracestate` header value.
+func (ts TraceState) String() string {
+	var b strings.Builder
+	for i, m := range ts.members {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(m.Key)
+		b.WriteByte('=')
+		b.WriteString(m.Value)
+	}
+	return b.String()
+}
+
+// Len returns the number of list members.
+func (ts TraceState) Len() int {
+	return len(ts.members)
+}
+
+// Members returns a copy of the list members, in header order.
+func (ts TraceState) Members() []TraceStateMember {
+	return append([]TraceStateMember(nil), ts.members...)
+}
+
+// Get returns the value for `key`, or "" if it is not present.
+func (ts TraceState) Get(key string) string {
+	for _, m := range ts.members {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// Set returns a copy of the TraceState with `key` set to `value` and moved
+// to the front of the list, as the spec requires for updated entries. If
+// this would exceed MaxTraceStateMembers, the right-most member is dropped.
+func (ts TraceState) Set(key, value string) (TraceState, error) {
+	if !validKey(key) || !validValue(value) {
+		return ts, opentracing.ErrSpanContextCorrupted
+	}
+	members := make([]TraceStateMember, 0, len(ts.members)+1)
+	members = append(members, TraceStateMember{Key: key, Value: value})
+	for _, m := range ts.members {
+		if m.Key != key {
+			members = append(members, m)
+		}
+	}
+	if len(members) > MaxTraceStateMembers {
+		members = members[:MaxTraceStateMembers]
+	}
+	return TraceState{members: members}, nil
+}
+
+// Delete returns a copy of the TraceState without `key`.
+func (ts TraceState) Delete(key string) TraceState {
+	members := make([]TraceStateMember, 0, len(ts.members))
+	for _, m := range ts.members {
+		if m.Key != key {
+			members = append(members, m)
+		}
+	}
+	return TraceState{members: members}
+}
+
+// validKey checks a key against the spec grammar:
+//
+//     key = simple-key / multi-tenant-key
+//     simple-key = lcalpha 0*255( lcalpha / DIGIT / "_" / "-"/ "*" / "/" )
+//     multi-tenant-key = tenant-id "@" system-id
+//     tenant-id = ( lcalpha / DIGIT ) 0*240( lcalpha / DIGIT / "_" / "-"/ "*" / "/" )
+//     system-id = lcalpha 0*13( lcalpha / DIGIT / "_" / "-"/ "*" / "/" )
+func validKey(key string) bool {
+	at := strings.IndexByte(key, '@')
+	if at < 0 {
+		return len(key) <= maxKeyLen && isLowerAlpha(key, 0) && validKeyChars(key[1:])
+	}
+	tenant, system := key[:at], key[at+1:]
+	return len(tenant) > 0 && len(tenant) <= maxTenantIDLen &&
+		(isLowerAlpha(tenant, 0) || isDigit(tenant, 0)) && validKeyChars(tenant[1:]) &&
+		len(system) <= maxSystemIDLen && isLowerAlpha(system, 0) && validKeyChars(system[1:])
+}
+
+func validKeyChars(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isLowerAlpha(s, i) && !isDigit(s, i) &&
+			s[i] != '_' && s[i] != '-' && s[i] != '*' && s[i] != '/' {
+			return false
+		}
+	}
+	return true
+}
+
+// validValue checks a value against the spec grammar:
+//
+//     value = 0*255(chr) nblk-chr
+//     nblk-chr = %x21-2B / %x2D-3C / %x3E-7E
+//     chr = %x20 / nblk-chr
+func validValue(value string) bool {
+	if len(value) == 0 || len(value) > maxValueLen || value[len(value)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLowerAlpha(s string, i int) bool {
+	return i < len(s) && 'a' <= s[i] && s[i] <= 'z'
+}
+
+func isDigit(s string, i int) bool {
+	return i < len(s) && '0' <= s[i] && s[i] <= '9'
+}
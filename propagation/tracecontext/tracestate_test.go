@@ -0,0 +1,78 @@
+package tracecontext
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+func TestParseTraceState(t *testing.T) {
+	ts, err := ParseTraceState(" rojo=00f067aa0ba902b7 ,\t,congo=t61rcWkgMzE,tenant@vendor=a b ")
+	require.NoError(t, err)
+	assert.Equal(t, []TraceStateMember{
+		{Key: "rojo", Value: "00f067aa0ba902b7"},
+		{Key: "congo", Value: "t61rcWkgMzE"},
+		{Key: "tenant@vendor", Value: "a b"},
+	}, ts.Members())
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE,tenant@vendor=a b", ts.String())
+	assert.Equal(t, "t61rcWkgMzE", ts.Get("congo"))
+	assert.Equal(t, "", ts.Get("missing"))
+}
+
+func TestParseTraceState_Invalid(t *testing.T) {
+	tooMany := make([]string, MaxTraceStateMembers+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("k%d=v", i)
+	}
+	tests := []string{
+		"novalue",
+		"key=",
+		"Upper=1",
+		"1digit=1",
+		"key=val,key=dup",
+		"key=a=b",
+		"@vendor=1",
+		"tenant@=1",
+		"tenant@toolongsystemid=1",
+		"key=\x7f",
+		"k=" + strings.Repeat("v", maxValueLen+1),
+		strings.Join(tooMany, ","),
+	}
+	for _, test := range tests {
+		_, err := ParseTraceState(test)
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, test)
+	}
+}
+
+func TestTraceState_SetDelete(t *testing.T) {
+	ts, err := ParseTraceState("a=1,b=2,c=3")
+	require.NoError(t, err)
+
+	updated, err := ts.Set("b", "two")
+	require.NoError(t, err)
+	assert.Equal(t, "b=two,a=1,c=3", updated.String())
+	assert.Equal(t, "a=1,b=2,c=3", ts.String(), "original must not change")
+
+	assert.Equal(t, "a=1,c=3", ts.Delete("b").String())
+
+	_, err = ts.Set("Bad", "x")
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+	_, err = ts.Set("key", "trailing ")
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+
+	var full TraceState
+	for i := 0; i < MaxTraceStateMembers; i++ {
+		full, err = full.Set(fmt.Sprintf("k%d", i), "v")
+		require.NoError(t, err)
+	}
+	full, err = full.Set("newest", "v")
+	require.NoError(t, err)
+	assert.Equal(t, MaxTraceStateMembers, full.Len())
+	assert.Equal(t, "newest", full.Members()[0].Key)
+	assert.Equal(t, "", full.Get("k0"), "right-most member is dropped")
+}
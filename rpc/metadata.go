@@ -0,0 +1,46 @@
+// Package rpc provides OpenTracing instrumentation for RPC frameworks in the
+// shape of gRPC interceptors, without depending on any RPC framework.
+//
+// Interceptors receive request metadata as a Metadata value, which has the
+// same representation as gRPC's metadata.MD (map[string][]string), so a
+// thin adapter is enough to use them with gRPC:
+//
+//     func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+//         md, _ := metadata.FromIncomingContext(ctx)
+//         return interceptor(ctx, info.FullMethod, rpc.Metadata(md), req, rpc.UnaryHandler(handler))
+//     }
+package rpc
+
+import "strings"
+
+// Metadata holds RPC request metadata as a multimap with lower-case keys. It
+// implements opentracing.TextMapReader and opentracing.TextMapWriter.
+type Metadata map[string][]string
+
+// Set conforms to the TextMapWriter interface. Keys are lower-cased, as
+// HTTP/2 requires, and any existing values for the key are replaced.
+func (m Metadata) Set(key, val string) {
+	m[strings.ToLower(key)] = []string{val}
+}
+
+// ForeachKey conforms to the TextMapReader interface.
+func (m Metadata) ForeachKey(handler func(key, val string) error) error {
+	for k, vals := range m {
+		for _, v := range vals {
+			if err := handler(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Copy returns a deep copy of m. The copy of a nil Metadata is empty but
+// not nil.
+func (m Metadata) Copy() Metadata {
+	out := make(Metadata, len(m))
+	for k, vals := range m {
+		out[k] = append([]string(nil), vals...)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+type options struct {
+	spanFilter    func(method string) bool
+	opNameFunc    func(method string) string
+	spanDecorator func(span opentracing.Span, method string, req, resp interface{}, err error)
+	logPayloads   bool
+}
+
+// Option controls the behavior of the interceptors.
+type Option func(*options)
+
+// SpanFilter returns an Option that filters methods from creating a span. A
+// span is only created if the filter returns true.
+func SpanFilter(f func(method string) bool) Option {
+	return func(o *options) {
+		o.spanFilter = f
+	}
+}
+
+// OperationNameFunc returns an Option that uses given function f to
+// generate the operation name from the method name. By default, the method
+// name is used as is.
+func OperationNameFunc(f func(method string) string) Option {
+	return func(o *options) {
+		o.opNameFunc = f
+	}
+}
+
+// SpanDecorator returns an Option that calls f when a unary call completes,
+// e.g. to set additional tags from the request or response. For streams,
+// req and resp are nil.
+func SpanDecorator(f func(span opentracing.Span, method string, req, resp interface{}, err error)) Option {
+	return func(o *options) {
+		o.spanDecorator = f
+	}
+}
+
+// LogPayloads returns an Option that turns on or off logging of request and
+// response messages as span log fields.
+func LogPayloads(enabled bool) Option {
+	return func(o *options) {
+		o.logPayloads = enabled
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		spanFilter:    func(string) bool { return true },
+		opNameFunc:    func(method string) string { return method },
+		spanDecorator: func(opentracing.Span, string, interface{}, interface{}, error) {},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Stream is the part of grpc.ServerStream and grpc.ClientStream that the
+// interceptors need.
+type Stream interface {
+	Context() context.Context
+	SendMsg(m interface{}) error
+	RecvMsg(m interface{}) error
+}
+
+// ClientStream is a Stream that the client can half-close, like
+// grpc.ClientStream.
+type ClientStream interface {
+	Stream
+	CloseSend() error
+}
+
+// StreamHandler handles a stream on the server, like grpc.StreamHandler.
+type StreamHandler func(stream Stream) error
+
+// StreamServerInterceptor intercepts a stream on the server, like
+// grpc.StreamServerInterceptor.
+type StreamServerInterceptor func(method string, md Metadata, stream Stream, handler StreamHandler) error
+
+// Streamer opens a stream from the client, like grpc.Streamer. `md` is the
+// outgoing metadata.
+type Streamer func(ctx context.Context, method string, md Metadata) (ClientStream, error)
+
+// StreamClientInterceptor intercepts the opening of a stream on the client,
+// like grpc.StreamClientInterceptor.
+type StreamClientInterceptor func(ctx context.Context, method string, md Metadata, streamer Streamer) (ClientStream, error)
+
+// StreamServer returns a StreamServerInterceptor that starts a
+// server span for every stream, as a child of the span context extracted
+// from the incoming metadata. Every message sent or received is logged as a
+// span event, and the span finishes when the handler returns.
+func StreamServer(tracer opentracing.Tracer, opts ...Option) StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(method string, md Metadata, stream Stream, handler StreamHandler) (err error) {
+		if !o.spanFilter(method) {
+			return handler(stream)
+		}
+		sp := startServerSpan(tracer, o, method, md)
+		defer func() {
+			if err != nil {
+				ext.LogError(sp, err)
+			}
+			o.spanDecorator(sp, method, nil, nil, err)
+			sp.Finish()
+		}()
+		return handler(&tracedServerStream{
+			Stream: stream,
+			ctx:    opentracing.ContextWithSpan(stream.Context(), sp),
+			sp:     sp,
+			opts:   o,
+		})
+	}
+}
+
+// StreamClient returns a StreamClientInterceptor that starts
+// a client span for every stream. Every message sent or received is logged
+// as a span event. The span finishes when RecvMsg returns io.EOF or any
+// error, or when SendMsg or CloseSend fails.
+func StreamClient(tracer opentracing.Tracer, opts ...Option) StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, md Metadata, streamer Streamer) (ClientStream, error) {
+		if !o.spanFilter(method) {
+			return streamer(ctx, method, md)
+		}
+		sp, md := startClientSpan(ctx, tracer, o, method, md)
+		cs, err := streamer(opentracing.ContextWithSpan(ctx, sp), method, md)
+		if err != nil {
+			ext.LogError(sp, err)
+			o.spanDecorator(sp, method, nil, nil, err)
+			sp.Finish()
+			return nil, err
+		}
+		return &tracedClientStream{ClientStream: cs, sp: sp, method: method, opts: o}, nil
+	}
+}
+
+func logMessage(sp opentracing.Span, o options, event string, m interface{}) {
+	if o.logPayloads {
+		sp.LogFields(log.Event(event), log.Object("message", m))
+	} else {
+		sp.LogFields(log.Event(event))
+	}
+}
+
+type tracedServerStream struct {
+	Stream
+	ctx  context.Context
+	sp   opentracing.Span
+	opts options
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracedServerStream) SendMsg(m interface{}) error {
+	err := s.Stream.SendMsg(m)
+	if err == nil {
+		logMessage(s.sp, s.opts, "message sent", m)
+	}
+	return err
+}
+
+func (s *tracedServerStream) RecvMsg(m interface{}) error {
+	err := s.Stream.RecvMsg(m)
+	switch err {
+	case nil:
+		logMessage(s.sp, s.opts, "message received", m)
+	case io.EOF:
+		s.sp.LogFields(log.Event("client closed"))
+	}
+	return err
+}
+
+type tracedClientStream struct {
+	ClientStream
+	sp     opentracing.Span
+	method string
+	opts   options
+
+	mu       sync.Mutex
+	finished bool
+}
+
+func (s *tracedClientStream) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.finished = true
+	if err != nil && err != io.EOF {
+		ext.LogError(s.sp, err)
+		s.opts.spanDecorator(s.sp, s.method, nil, nil, err)
+	} else {
+		s.opts.spanDecorator(s.sp, s.method, nil, nil, nil)
+	}
+	s.sp.Finish()
+}
+
+// log calls fn to log on the span unless the stream has already finished
+// it, as happens when SendMsg or CloseSend is called after RecvMsg ended
+// the stream.
+func (s *tracedClientStream) log(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		fn()
+	}
+}
+
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.finish(err)
+	} else {
+		s.log(func() { logMessage(s.sp, s.opts, "message sent", m) })
+	}
+	return err
+}
+
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.finish(err)
+	} else {
+		s.log(func() { logMessage(s.sp, s.opts, "message received", m) })
+	}
+	return err
+}
+
+func (s *tracedClientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.finish(err)
+	} else {
+		s.log(func() { s.sp.LogFields(log.Event("close send")) })
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// fakeStream replays `recv` to RecvMsg, then returns `recvErr`.
+type fakeStream struct {
+	ctx     context.Context
+	recv    []string
+	recvErr error
+	sendErr error
+	sent    []interface{}
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if len(s.recv) == 0 {
+		return s.recvErr
+	}
+	*m.(*string) = s.recv[0]
+	s.recv = s.recv[1:]
+	return nil
+}
+
+func (s *fakeStream) CloseSend() error {
+	return nil
+}
+
+func events(sp *mocktracer.MockSpan) []string {
+	var out []string
+	for _, rec := range sp.Logs() {
+		for _, f := range rec.Fields {
+			if f.Key == "event" {
+				out = append(out, f.ValueString)
+			}
+		}
+	}
+	return out
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	tr := mocktracer.New()
+	parent := tr.StartSpan("client")
+	md := Metadata{}
+	require.NoError(t, tr.Inject(parent.Context(), opentracing.TextMap, md))
+
+	stream := &fakeStream{ctx: context.Background(), recv: []string{"a", "b"}, recvErr: io.EOF}
+	err := StreamServer(tr)("/svc/Echo", md, stream, func(s Stream) error {
+		assert.NotNil(t, opentracing.SpanFromContext(s.Context()))
+		for {
+			var m string
+			if err := s.RecvMsg(&m); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := s.SendMsg(m); err != nil {
+				return err
+			}
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, stream.sent)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	sp := spans[0]
+	assert.Equal(t, ext.SpanKindRPCServerEnum, sp.Tag(string(ext.SpanKind)))
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, sp.ParentID)
+	assert.Equal(t, []string{
+		"message received", "message sent",
+		"message received", "message sent",
+		"client closed",
+	}, events(sp))
+}
+
+func TestStreamServerInterceptorError(t *testing.T) {
+	tr := mocktracer.New()
+	errFailed := errors.New("failed")
+	stream := &fakeStream{ctx: context.Background()}
+	err := StreamServer(tr)("/svc/Echo", nil, stream, func(s Stream) error {
+		return errFailed
+	})
+	assert.Equal(t, errFailed, err)
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, true, spans[0].Tag(string(ext.Error)))
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	tr := mocktracer.New()
+	stream := &fakeStream{ctx: context.Background(), recv: []string{"x"}, recvErr: io.EOF}
+	var sentMD Metadata
+	cs, err := StreamClient(tr)(context.Background(), "/svc/List", nil,
+		func(ctx context.Context, method string, md Metadata) (ClientStream, error) {
+			sentMD = md
+			return stream, nil
+		})
+	require.NoError(t, err)
+	sc, err := tr.Extract(opentracing.TextMap, sentMD)
+	require.NoError(t, err)
+
+	require.NoError(t, cs.SendMsg("req"))
+	require.NoError(t, cs.CloseSend())
+	var m string
+	require.NoError(t, cs.RecvMsg(&m))
+	assert.Equal(t, "x", m)
+	assert.Empty(t, tr.FinishedSpans(), "span must stay open until the stream ends")
+
+	assert.Equal(t, io.EOF, cs.RecvMsg(&m))
+	assert.Equal(t, io.EOF, cs.RecvMsg(&m))
+	require.NoError(t, cs.SendMsg("late"))
+	require.NoError(t, cs.CloseSend())
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	sp := spans[0]
+	assert.Equal(t, sc.(mocktracer.MockSpanContext).SpanID, sp.SpanContext.SpanID)
+	assert.Equal(t, ext.SpanKindRPCClientEnum, sp.Tag(string(ext.SpanKind)))
+	assert.Nil(t, sp.Tag(string(ext.Error)))
+	assert.Equal(t, []string{"message sent", "close send", "message received"}, events(sp))
+}
+
+func TestStreamClientInterceptorErrors(t *testing.T) {
+	tr := mocktracer.New()
+	errFailed := errors.New("failed")
+
+	_, err := StreamClient(tr)(context.Background(), "/svc/List", nil,
+		func(ctx context.Context, method string, md Metadata) (ClientStream, error) {
+			return nil, errFailed
+		})
+	assert.Equal(t, errFailed, err)
+
+	stream := &fakeStream{ctx: context.Background(), sendErr: errFailed}
+	cs, err := StreamClient(tr)(context.Background(), "/svc/List", nil,
+		func(ctx context.Context, method string, md Metadata) (ClientStream, error) {
+			return stream, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, errFailed, cs.SendMsg("req"))
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, sp := range spans {
+		assert.Equal(t, true, sp.Tag(string(ext.Error)))
+	}
+}
@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+const componentName = "rpc"
+
+// UnaryHandler handles a unary call on the server, like grpc.UnaryHandler.
+type UnaryHandler func(ctx context.Context, req interface{}) (interface{}, error)
+
+// UnaryServerInterceptor intercepts a unary call on the server, like
+// grpc.UnaryServerInterceptor.
+type UnaryServerInterceptor func(ctx context.Context, method string, md Metadata, req interface{}, handler UnaryHandler) (interface{}, error)
+
+// UnaryInvoker sends a unary call from the client, like grpc.UnaryInvoker.
+// `md` is the outgoing metadata.
+type UnaryInvoker func(ctx context.Context, method string, req, reply interface{}, md Metadata) error
+
+// UnaryClientInterceptor intercepts a unary call on the client, like
+// grpc.UnaryClientInterceptor.
+type UnaryClientInterceptor func(ctx context.Context, method string, req, reply interface{}, md Metadata, invoker UnaryInvoker) error
+
+// UnaryServer returns a UnaryServerInterceptor that starts a server
+// span for every call, as a child of the span context extracted from the
+// incoming metadata, and makes it available to the handler through
+// opentracing.SpanFromContext.
+func UnaryServer(tracer opentracing.Tracer, opts ...Option) UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, md Metadata, req interface{}, handler UnaryHandler) (resp interface{}, err error) {
+		if !o.spanFilter(method) {
+			return handler(ctx, req)
+		}
+		sp := startServerSpan(tracer, o, method, md)
+		defer func() {
+			if err != nil {
+				ext.LogError(sp, err)
+			} else if o.logPayloads {
+				sp.LogFields(log.Object("response", resp))
+			}
+			o.spanDecorator(sp, method, req, resp, err)
+			sp.Finish()
+		}()
+		if o.logPayloads {
+			sp.LogFields(log.Object("request", req))
+		}
+		return handler(opentracing.ContextWithSpan(ctx, sp), req)
+	}
+}
+
+// UnaryClient returns a UnaryClientInterceptor that starts a client
+// span for every call, as a child of the span in `ctx` if any, and injects
+// it into a copy of the outgoing metadata.
+func UnaryClient(tracer opentracing.Tracer, opts ...Option) UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, md Metadata, invoker UnaryInvoker) (err error) {
+		if !o.spanFilter(method) {
+			return invoker(ctx, method, req, reply, md)
+		}
+		sp, md := startClientSpan(ctx, tracer, o, method, md)
+		defer func() {
+			if err != nil {
+				ext.LogError(sp, err)
+			} else if o.logPayloads {
+				sp.LogFields(log.Object("response", reply))
+			}
+			o.spanDecorator(sp, method, req, reply, err)
+			sp.Finish()
+		}()
+		if o.logPayloads {
+			sp.LogFields(log.Object("request", req))
+		}
+		return invoker(opentracing.ContextWithSpan(ctx, sp), method, req, reply, md)
+	}
+}
+
+func startServerSpan(tracer opentracing.Tracer, o options, method string, md Metadata) opentracing.Span {
+	parentCtx, err := tracer.Extract(opentracing.TextMap, md)
+	sp := tracer.StartSpan(o.opNameFunc(method), ext.RPCServerOption(parentCtx))
+	ext.Component.Set(sp, componentName)
+	if err != nil && err != opentracing.ErrSpanContextNotFound {
+		sp.LogFields(log.String("event", "extract failed"), log.Error(err))
+	}
+	return sp
+}
+
+func startClientSpan(ctx context.Context, tracer opentracing.Tracer, o options, method string, md Metadata) (opentracing.Span, Metadata) {
+	var parentCtx opentracing.SpanContext
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		parentCtx = parent.Context()
+	}
+	sp := tracer.StartSpan(o.opNameFunc(method), opentracing.ChildOf(parentCtx), ext.SpanKindRPCClient)
+	ext.Component.Set(sp, componentName)
+	md = md.Copy()
+	if err := tracer.Inject(sp.Context(), opentracing.TextMap, md); err != nil {
+		sp.LogFields(log.String("event", "inject failed"), log.Error(err))
+	}
+	return sp, md
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestMetadata(t *testing.T) {
+	md := Metadata{"existing": {"a", "b"}}
+	md.Set("Mixed-Case", "v")
+	assert.Equal(t, []string{"v"}, md["mixed-case"])
+
+	seen := map[string][]string{}
+	require.NoError(t, md.ForeachKey(func(k, v string) error {
+		seen[k] = append(seen[k], v)
+		return nil
+	}))
+	assert.Equal(t, map[string][]string(md), seen)
+
+	cp := md.Copy()
+	cp["existing"][0] = "changed"
+	assert.Equal(t, "a", md["existing"][0])
+	assert.NotNil(t, Metadata(nil).Copy())
+
+	errStop := errors.New("stop")
+	assert.Equal(t, errStop, md.ForeachKey(func(k, v string) error { return errStop }))
+}
+
+// clientServer connects a client interceptor to a server interceptor
+// in-process, the way a transport would carry metadata between them.
+func clientServer(client UnaryClientInterceptor, server UnaryServerInterceptor, handler UnaryHandler) func(ctx context.Context, method string, req interface{}) (interface{}, error) {
+	return func(ctx context.Context, method string, req interface{}) (interface{}, error) {
+		var reply interface{}
+		err := client(ctx, method, req, nil, nil, func(ctx context.Context, method string, req, _ interface{}, md Metadata) error {
+			resp, err := server(context.Background(), method, md, req, handler)
+			reply = resp
+			return err
+		})
+		return reply, err
+	}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	tr := mocktracer.New()
+	var handlerSpan opentracing.Span
+	call := clientServer(
+		UnaryClient(tr),
+		UnaryServer(tr, LogPayloads(true)),
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerSpan = opentracing.SpanFromContext(ctx)
+			return "pong", nil
+		},
+	)
+
+	parent := tr.StartSpan("parent")
+	resp, err := call(opentracing.ContextWithSpan(context.Background(), parent), "/svc/Ping", "ping")
+	require.NoError(t, err)
+	assert.Equal(t, "pong", resp)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 2)
+	server, client := spans[0], spans[1]
+	assert.Same(t, server, handlerSpan)
+
+	assert.Equal(t, "/svc/Ping", client.OperationName)
+	assert.Equal(t, ext.SpanKindRPCClientEnum, client.Tag(string(ext.SpanKind)))
+	assert.Equal(t, componentName, client.Tag(string(ext.Component)))
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, client.ParentID)
+
+	assert.Equal(t, "/svc/Ping", server.OperationName)
+	assert.Equal(t, ext.SpanKindRPCServerEnum, server.Tag(string(ext.SpanKind)))
+	assert.Equal(t, client.SpanContext.SpanID, server.ParentID)
+	assert.Equal(t, client.SpanContext.TraceID, server.SpanContext.TraceID)
+
+	logs := server.Logs()
+	require.Len(t, logs, 2)
+	assert.Equal(t, "request", logs[0].Fields[0].Key)
+	assert.Equal(t, "ping", logs[0].Fields[0].ValueString)
+	assert.Equal(t, "response", logs[1].Fields[0].Key)
+	assert.Equal(t, "pong", logs[1].Fields[0].ValueString)
+	assert.Empty(t, client.Logs())
+}
+
+func TestUnaryInterceptorsError(t *testing.T) {
+	tr := mocktracer.New()
+	errFailed := errors.New("failed")
+	var decorated []string
+	decorator := SpanDecorator(func(sp opentracing.Span, method string, req, resp interface{}, err error) {
+		assert.Equal(t, errFailed, err)
+		decorated = append(decorated, method)
+	})
+	call := clientServer(
+		UnaryClient(tr, decorator),
+		UnaryServer(tr, decorator),
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, errFailed
+		},
+	)
+
+	_, err := call(context.Background(), "/svc/Fail", nil)
+	assert.Equal(t, errFailed, err)
+
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, sp := range spans {
+		assert.Equal(t, true, sp.Tag(string(ext.Error)))
+		require.Len(t, sp.Logs(), 1)
+	}
+	assert.Equal(t, []string{"/svc/Fail", "/svc/Fail"}, decorated)
+}
+
+func TestUnaryInterceptorsOptions(t *testing.T) {
+	tr := mocktracer.New()
+	call := clientServer(
+		UnaryClient(tr, SpanFilter(func(method string) bool { return method != "/svc/Health" })),
+		UnaryServer(tr, OperationNameFunc(func(method string) string { return "server " + method })),
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	)
+
+	_, err := call(context.Background(), "/svc/Health", nil)
+	require.NoError(t, err)
+	spans := tr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "server /svc/Health", spans[0].OperationName)
+	assert.Equal(t, 0, spans[0].ParentID)
+}
+
+func TestClientInterceptorCopiesMetadata(t *testing.T) {
+	tr := mocktracer.New()
+	md := Metadata{"authorization": {"secret"}}
+	var sent Metadata
+	err := UnaryClient(tr)(context.Background(), "/svc/M", nil, nil, md,
+		func(ctx context.Context, method string, req, reply interface{}, md Metadata) error {
+			sent = md
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Len(t, md, 1, "caller's metadata must not be modified")
+	assert.Equal(t, []string{"secret"}, sent["authorization"])
+	assert.Greater(t, len(sent), 1)
+}
@@ -0,0 +1,118 @@
+package sampling
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMaxOperations is the default of PerOperationOptions.MaxOperations.
+const DefaultMaxOperations = 2000
+
+// PerOperationOptions configures a PerOperation sampler.
+type PerOperationOptions struct {
+	// DefaultRate is the sampling rate of operations missing from Rates.
+	DefaultRate float64
+
+	// Rates holds the sampling rates of individual operations.
+	Rates map[string]float64
+
+	// LowerBound is the number of traces per second that are sampled for
+	// each operation regardless of its rate, so that rare operations are
+	// represented. Zero disables the lower bound.
+	LowerBound float64
+
+	// MaxOperations bounds the number of operations with their own
+	// sampler. Further operations are sampled at DefaultRate without lower
+	// bound. Zero means DefaultMaxOperations.
+	MaxOperations int
+}
+
+// PerOperation samples each operation with its own probability, while
+// guaranteeing a lower bound of sampled traces per second and operation.
+// The rates can be adapted at runtime with Update, e.g. from a central
+// sampling strategy.
+type PerOperation struct {
+	mu         sync.Mutex
+	opts       PerOperationOptions
+	operations map[string]*operationSampler
+	fallback   *Probabilistic
+	now        func() time.Time
+}
+
+type operationSampler struct {
+	probabilistic *Probabilistic
+	lowerBound    *tokenBucket
+}
+
+// NewPerOperation returns a PerOperation sampler.
+func NewPerOperation(opts PerOperationOptions) *PerOperation {
+	return newPerOperation(opts, time.Now)
+}
+
+func newPerOperation(opts PerOperationOptions, now func() time.Time) *PerOperation {
+	if opts.MaxOperations == 0 {
+		opts.MaxOperations = DefaultMaxOperations
+	}
+	s := &PerOperation{now: now}
+	s.Update(opts.DefaultRate, opts.Rates)
+	s.opts.LowerBound = opts.LowerBound
+	s.opts.MaxOperations = opts.MaxOperations
+	return s
+}
+
+// Update replaces the default and per-operation sampling rates. Lower
+// bound budgets of known operations are preserved.
+func (s *PerOperation) Update(defaultRate float64, rates map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts.DefaultRate = defaultRate
+	s.opts.Rates = make(map[string]float64, len(rates))
+	for op, rate := range rates {
+		s.opts.Rates[op] = rate
+	}
+	s.fallback = NewProbabilistic(defaultRate)
+	for op, sampler := range s.operations {
+		sampler.probabilistic = s.rateSampler(op)
+	}
+}
+
+// rateSampler returns the probabilistic sampler for `op`. The caller MUST
+// hold s.mu.
+func (s *PerOperation) rateSampler(op string) *Probabilistic {
+	if rate, ok := s.opts.Rates[op]; ok {
+		return NewProbabilistic(rate)
+	}
+	return s.fallback
+}
+
+// Sample belongs to the Sampler interface.
+func (s *PerOperation) Sample(params Params) Decision {
+	s.mu.Lock()
+	sampler, ok := s.operations[params.OperationName]
+	if !ok {
+		if len(s.operations) >= s.opts.MaxOperations {
+			fallback := s.fallback
+			s.mu.Unlock()
+			return fallback.Sample(params)
+		}
+		if s.operations == nil {
+			s.operations = make(map[string]*operationSampler)
+		}
+		sampler = &operationSampler{
+			probabilistic: s.rateSampler(params.OperationName),
+			lowerBound:    newTokenBucket(s.opts.LowerBound, s.now),
+		}
+		s.operations[params.OperationName] = sampler
+	}
+	probabilistic, lowerBound := sampler.probabilistic, s.opts.LowerBound
+	s.mu.Unlock()
+
+	decision := probabilistic.Sample(params)
+	// Sampled traces count towards the lower bound too, so that it bounds
+	// the total throughput of the operation from below.
+	withinBound := lowerBound > 0 && sampler.lowerBound.take()
+	if !decision.Sampled && withinBound {
+		return Decision{Sampled: true, SamplerType: TypeLowerBound, SamplerParam: lowerBound}
+	}
+	return decision
+}
@@ -0,0 +1,42 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+)
+
+// maxTraceID bounds the 63 bits of a trace ID used for probabilistic
+// decisions, so that the boundary computation cannot overflow.
+const maxTraceID = math.MaxInt64
+
+// Probabilistic samples traces with a fixed probability.
+type Probabilistic struct {
+	rate     float64
+	boundary uint64
+}
+
+// NewProbabilistic returns a Probabilistic sampler that samples a fraction
+// `rate` of the traces. The rate is clamped to [0, 1].
+func NewProbabilistic(rate float64) *Probabilistic {
+	rate = math.Max(0, math.Min(1, rate))
+	return &Probabilistic{rate: rate, boundary: uint64(rate * maxTraceID)}
+}
+
+// Rate returns the sampling rate.
+func (s *Probabilistic) Rate() float64 {
+	return s.rate
+}
+
+// Sample belongs to the Sampler interface. It decides from the lower 63
+// bits of params.TraceID if set, or at random otherwise.
+func (s *Probabilistic) Sample(params Params) Decision {
+	id := params.TraceID
+	if id == 0 {
+		id = rand.Uint64()
+	}
+	return Decision{
+		Sampled:      s.rate == 1 || id&maxTraceID < s.boundary,
+		SamplerType:  TypeProbabilistic,
+		SamplerParam: s.rate,
+	}
+}
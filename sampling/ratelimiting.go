@@ -0,0 +1,69 @@
+package sampling
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiting samples up to a fixed number of traces per second, using a
+// token bucket that allows bursts of up to one second's worth of traces.
+type RateLimiting struct {
+	perSecond float64
+	bucket    *tokenBucket
+}
+
+// NewRateLimiting returns a RateLimiting sampler that samples up to
+// `perSecond` traces per second.
+func NewRateLimiting(perSecond float64) *RateLimiting {
+	return &RateLimiting{perSecond: perSecond, bucket: newTokenBucket(perSecond, time.Now)}
+}
+
+// Sample belongs to the Sampler interface.
+func (s *RateLimiting) Sample(Params) Decision {
+	return Decision{
+		Sampled:      s.bucket.take(),
+		SamplerType:  TypeRateLimiting,
+		SamplerParam: s.perSecond,
+	}
+}
+
+type tokenBucket struct {
+	sync.Mutex
+	perSecond float64
+	capacity  float64
+	tokens    float64
+	last      time.Time
+	now       func() time.Time
+}
+
+// newTokenBucket returns a full bucket refilling `perSecond` tokens per
+// second, holding at least one token so that rates below one per second
+// can be sampled at all. A bucket with a rate of zero or less is empty and
+// never refills.
+func newTokenBucket(perSecond float64, now func() time.Time) *tokenBucket {
+	if perSecond <= 0 {
+		return &tokenBucket{last: now(), now: now}
+	}
+	capacity := math.Max(perSecond, 1)
+	return &tokenBucket{
+		perSecond: perSecond,
+		capacity:  capacity,
+		tokens:    capacity,
+		last:      now(),
+		now:       now,
+	}
+}
+
+func (b *tokenBucket) take() bool {
+	b.Lock()
+	defer b.Unlock()
+	now := b.now()
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
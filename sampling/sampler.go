@@ -0,0 +1,114 @@
+// Package sampling provides head-based samplers that tracer implementations
+// and instrumentation can share.
+//
+// A Sampler decides whether a new trace is sampled. Its Decision is a
+// StartSpanOption that records the decision on the root span through
+// ext.SamplingPriority, which tracers already honor, along with tags
+// describing the sampler:
+//
+//     sampler := sampling.NewProbabilistic(0.1)
+//     ...
+//     decision := sampler.Sample(sampling.Params{OperationName: "GET /x"})
+//     span := tracer.StartSpan("GET /x", decision)
+//
+// Tracer implementations can read the decision back from the
+// StartSpanOptions with DecisionFromOptions.
+package sampling
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	// SamplerTypeTag records the type of the sampler that made the
+	// decision, e.g. "probabilistic".
+	SamplerTypeTag = "sampler.type"
+
+	// SamplerParamTag records the parameter of the sampler that made the
+	// decision, e.g. the sampling rate.
+	SamplerParamTag = "sampler.param"
+)
+
+// Sampler types recorded in SamplerTypeTag.
+const (
+	TypeConst         = "const"
+	TypeProbabilistic = "probabilistic"
+	TypeRateLimiting  = "ratelimiting"
+	TypeLowerBound    = "lowerbound"
+)
+
+// Params are the inputs of a sampling decision.
+type Params struct {
+	OperationName string
+
+	// TraceID, if not 0, makes probabilistic decisions consistent: all
+	// samplers with the same rate decide the same for a given trace.
+	TraceID uint64
+}
+
+// Sampler decides whether new traces are sampled. Implementations must be
+// safe for concurrent use.
+type Sampler interface {
+	Sample(params Params) Decision
+}
+
+// Decision is the outcome of Sampler.Sample().
+//
+// It is also a StartSpanOption that sets ext.SamplingPriority to 1 or 0 and
+// records SamplerTypeTag and SamplerParamTag.
+type Decision struct {
+	Sampled      bool
+	SamplerType  string
+	SamplerParam float64
+}
+
+// Apply satisfies the StartSpanOption interface.
+func (d Decision) Apply(o *opentracing.StartSpanOptions) {
+	priority := uint16(0)
+	if d.Sampled {
+		priority = 1
+	}
+	opentracing.Tags{
+		string(ext.SamplingPriority): priority,
+		SamplerTypeTag:               d.SamplerType,
+		SamplerParamTag:              d.SamplerParam,
+	}.Apply(o)
+}
+
+// DecisionFromOptions returns the Decision applied to `opts`, if any.
+func DecisionFromOptions(opts opentracing.StartSpanOptions) (Decision, bool) {
+	priority, ok := opts.Tags[string(ext.SamplingPriority)].(uint16)
+	if !ok {
+		return Decision{}, false
+	}
+	samplerType, _ := opts.Tags[SamplerTypeTag].(string)
+	param, _ := opts.Tags[SamplerParamTag].(float64)
+	return Decision{Sampled: priority > 0, SamplerType: samplerType, SamplerParam: param}, true
+}
+
+// ShouldSampleFunc adapts `s` to the func(traceID uint64) bool form used
+// by tracer options, such as basictracer.Options.ShouldSample.
+func ShouldSampleFunc(s Sampler) func(traceID uint64) bool {
+	return func(traceID uint64) bool {
+		return s.Sample(Params{TraceID: traceID}).Sampled
+	}
+}
+
+type constSampler bool
+
+var (
+	// Always samples every trace.
+	Always Sampler = constSampler(true)
+
+	// Never samples no trace.
+	Never Sampler = constSampler(false)
+)
+
+func (c constSampler) Sample(Params) Decision {
+	param := 0.0
+	if c {
+		param = 1
+	}
+	return Decision{Sampled: bool(c), SamplerType: TypeConst, SamplerParam: param}
+}
@@ -0,0 +1,144 @@
+package sampling
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// fakeClock returns a clock function and a function to advance it.
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestConst(t *testing.T) {
+	assert.Equal(t, Decision{Sampled: true, SamplerType: TypeConst, SamplerParam: 1}, Always.Sample(Params{}))
+	assert.Equal(t, Decision{Sampled: false, SamplerType: TypeConst, SamplerParam: 0}, Never.Sample(Params{}))
+}
+
+func TestProbabilistic(t *testing.T) {
+	s := NewProbabilistic(0.5)
+	assert.Equal(t, 0.5, s.Rate())
+	assert.True(t, s.Sample(Params{TraceID: 1}).Sampled)
+	assert.False(t, s.Sample(Params{TraceID: math.MaxInt64}).Sampled)
+	assert.True(t, s.Sample(Params{TraceID: 1 << 63}).Sampled, "the top bit is ignored")
+	assert.Equal(t, TypeProbabilistic, s.Sample(Params{}).SamplerType)
+
+	for _, id := range []uint64{1, 1 << 62, math.MaxUint64} {
+		assert.True(t, NewProbabilistic(1).Sample(Params{TraceID: id}).Sampled)
+		assert.True(t, NewProbabilistic(2).Sample(Params{TraceID: id}).Sampled)
+		assert.False(t, NewProbabilistic(0).Sample(Params{TraceID: id}).Sampled)
+		assert.False(t, NewProbabilistic(-1).Sample(Params{TraceID: id}).Sampled)
+	}
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		if NewProbabilistic(0.25).Sample(Params{}).Sampled {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 2500, sampled, 300)
+}
+
+func TestRateLimiting(t *testing.T) {
+	now, advance := fakeClock()
+	s := &RateLimiting{perSecond: 2, bucket: newTokenBucket(2, now)}
+	assert.Equal(t, Decision{Sampled: true, SamplerType: TypeRateLimiting, SamplerParam: 2}, s.Sample(Params{}))
+	assert.True(t, s.Sample(Params{}).Sampled)
+	assert.False(t, s.Sample(Params{}).Sampled, "burst is limited to one second's worth")
+	advance(250 * time.Millisecond)
+	assert.False(t, s.Sample(Params{}).Sampled)
+	advance(250 * time.Millisecond)
+	assert.True(t, s.Sample(Params{}).Sampled)
+	advance(time.Hour)
+	assert.True(t, s.Sample(Params{}).Sampled)
+	assert.True(t, s.Sample(Params{}).Sampled)
+	assert.False(t, s.Sample(Params{}).Sampled)
+
+	slow := &RateLimiting{perSecond: 0.5, bucket: newTokenBucket(0.5, now)}
+	assert.True(t, slow.Sample(Params{}).Sampled)
+	advance(time.Second)
+	assert.False(t, slow.Sample(Params{}).Sampled)
+	advance(time.Second)
+	assert.True(t, slow.Sample(Params{}).Sampled)
+
+	assert.True(t, NewRateLimiting(1).Sample(Params{}).Sampled)
+
+	never := &RateLimiting{bucket: newTokenBucket(0, now)}
+	for i := 0; i < 3; i++ {
+		assert.False(t, never.Sample(Params{}).Sampled)
+		advance(time.Hour)
+	}
+	assert.False(t, NewRateLimiting(-1).Sample(Params{}).Sampled)
+}
+
+func TestPerOperation(t *testing.T) {
+	now, advance := fakeClock()
+	s := newPerOperation(PerOperationOptions{
+		DefaultRate: 0,
+		Rates:       map[string]float64{"always": 1},
+		LowerBound:  1,
+	}, now)
+
+	assert.Equal(t, Decision{Sampled: true, SamplerType: TypeProbabilistic, SamplerParam: 1},
+		s.Sample(Params{OperationName: "always"}))
+	assert.True(t, s.Sample(Params{OperationName: "always"}).Sampled)
+
+	assert.Equal(t, Decision{Sampled: true, SamplerType: TypeLowerBound, SamplerParam: 1},
+		s.Sample(Params{OperationName: "rare"}))
+	assert.Equal(t, Decision{Sampled: false, SamplerType: TypeProbabilistic, SamplerParam: 0},
+		s.Sample(Params{OperationName: "rare"}))
+	assert.True(t, s.Sample(Params{OperationName: "other"}).Sampled, "lower bounds are per operation")
+	advance(time.Second)
+	assert.True(t, s.Sample(Params{OperationName: "rare"}).Sampled)
+
+	s.Update(1, map[string]float64{"always": 0})
+	assert.Equal(t, TypeProbabilistic, s.Sample(Params{OperationName: "rare"}).SamplerType)
+	assert.Equal(t, TypeLowerBound, s.Sample(Params{OperationName: "always"}).SamplerType,
+		"lower bound budget is preserved across updates")
+	assert.False(t, s.Sample(Params{OperationName: "always"}).Sampled)
+}
+
+func TestPerOperationMaxOperations(t *testing.T) {
+	s := NewPerOperation(PerOperationOptions{DefaultRate: 0, LowerBound: 10, MaxOperations: 1})
+	assert.True(t, s.Sample(Params{OperationName: "first"}).Sampled)
+	assert.Equal(t, Decision{Sampled: false, SamplerType: TypeProbabilistic, SamplerParam: 0},
+		s.Sample(Params{OperationName: "second"}), "operations beyond the limit have no lower bound")
+}
+
+func TestDecisionOption(t *testing.T) {
+	tracer := mocktracer.New()
+	sampled := tracer.StartSpan("op", Decision{Sampled: true, SamplerType: TypeConst, SamplerParam: 1}).(*mocktracer.MockSpan)
+	assert.True(t, sampled.SpanContext.Sampled)
+	assert.Equal(t, map[string]interface{}{SamplerTypeTag: TypeConst, SamplerParamTag: 1.0}, sampled.Tags())
+
+	unsampled := tracer.StartSpan("op", Never.Sample(Params{})).(*mocktracer.MockSpan)
+	assert.False(t, unsampled.SpanContext.Sampled)
+
+	var sso opentracing.StartSpanOptions
+	_, ok := DecisionFromOptions(sso)
+	assert.False(t, ok)
+	d := NewProbabilistic(0.5).Sample(Params{TraceID: 1})
+	d.Apply(&sso)
+	got, ok := DecisionFromOptions(sso)
+	require.True(t, ok)
+	assert.Equal(t, d, got)
+}
+
+func TestShouldSampleFunc(t *testing.T) {
+	recorder := basictracer.NewInMemoryRecorder()
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = recorder
+	opts.ShouldSample = ShouldSampleFunc(Never)
+	basictracer.NewWithOptions(opts).StartSpan("op").Finish()
+	assert.Empty(t, recorder.GetSampledSpans())
+	assert.Len(t, recorder.GetSpans(), 1)
+}
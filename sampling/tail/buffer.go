@@ -0,0 +1,253 @@
+package tail
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+const (
+	// DefaultMaxBytes is the default of Options.MaxBytes.
+	DefaultMaxBytes = 32 << 20
+
+	// DefaultTimeout is the default of Options.Timeout.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Options configures a Buffer.
+type Options struct {
+	// Policies decide which traces are kept. Without policies, no trace is
+	// kept.
+	Policies []Policy
+
+	// MaxBytes is the memory budget of buffered spans, see Span.Size. When
+	// it is exceeded, the oldest traces are decided early. Zero means
+	// DefaultMaxBytes.
+	MaxBytes int
+
+	// Timeout is how long after its first span a trace is decided. Spans
+	// arriving later than that follow the decision for another Timeout, and
+	// are forwarded by themselves if the trace was kept. Zero means
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Exporter receives the spans of kept traces.
+type Exporter interface {
+	Export(trace []Span)
+}
+
+// ExporterFunc is a function adapter for Exporter.
+type ExporterFunc func(trace []Span)
+
+// Export belongs to the Exporter interface.
+func (f ExporterFunc) Export(trace []Span) {
+	f(trace)
+}
+
+// RecorderExporter forwards the RawSpan payloads of kept traces to a
+// SpanRecorder of the reference tracer. Other spans are ignored.
+func RecorderExporter(recorder basictracer.SpanRecorder) Exporter {
+	return ExporterFunc(func(trace []Span) {
+		for _, span := range trace {
+			if raw, ok := span.Payload.(basictracer.RawSpan); ok {
+				recorder.RecordSpan(raw)
+			}
+		}
+	})
+}
+
+// Buffer buffers finished spans per trace and forwards the traces kept by
+// its policies to an Exporter. It is safe for concurrent use.
+type Buffer struct {
+	exporter Exporter
+	opts     Options
+	now      func() time.Time
+	done     chan struct{}
+	stopped  sync.WaitGroup
+
+	mu      sync.Mutex
+	closed  bool
+	bytes   int
+	pending map[string]*pendingTrace
+	order   []*pendingTrace // by first span
+	decided map[string]bool
+	expiry  []decision // by decision time
+}
+
+type pendingTrace struct {
+	id        string
+	firstSeen time.Time
+	spans     []Span
+	bytes     int
+}
+
+type decision struct {
+	id string
+	at time.Time
+}
+
+// New returns a Buffer forwarding kept traces to `exporter`. It decides
+// traces in the background until Close is called.
+func New(exporter Exporter, opts Options) *Buffer {
+	b := newBuffer(exporter, opts, time.Now)
+	b.stopped.Add(1)
+	go b.run()
+	return b
+}
+
+func newBuffer(exporter Exporter, opts Options, now func() time.Time) *Buffer {
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &Buffer{
+		exporter: exporter,
+		opts:     opts,
+		now:      now,
+		done:     make(chan struct{}),
+		pending:  make(map[string]*pendingTrace),
+		decided:  make(map[string]bool),
+	}
+}
+
+func (b *Buffer) run() {
+	defer b.stopped.Done()
+	interval := b.opts.Timeout / 10
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.expire()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// RecordSpan implements basictracer.SpanRecorder.
+func (b *Buffer) RecordSpan(raw basictracer.RawSpan) {
+	b.Add(FromRawSpan(raw))
+}
+
+// RecordMockSpan adds a finished MockSpan, e.g. received from
+// MockTracer.SubscribeFinishedSpans().
+func (b *Buffer) RecordMockSpan(span *mocktracer.MockSpan) {
+	b.Add(FromMockSpan(span))
+}
+
+// Add buffers a finished span. Spans added after Close are dropped.
+func (b *Buffer) Add(span Span) {
+	if span.Size == 0 {
+		span.Size = estimateSize(span)
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	if keep, ok := b.decided[span.TraceID]; ok {
+		b.mu.Unlock()
+		if keep {
+			b.exporter.Export([]Span{span})
+		}
+		return
+	}
+	trace, ok := b.pending[span.TraceID]
+	if !ok {
+		trace = &pendingTrace{id: span.TraceID, firstSeen: b.now()}
+		b.pending[span.TraceID] = trace
+		b.order = append(b.order, trace)
+	}
+	trace.spans = append(trace.spans, span)
+	trace.bytes += span.Size
+	b.bytes += span.Size
+
+	var kept [][]Span
+	for b.bytes > b.opts.MaxBytes && len(b.order) > 0 {
+		kept = b.decideOldest(kept)
+	}
+	b.mu.Unlock()
+	b.export(kept)
+}
+
+// Flush decides all buffered traces now.
+func (b *Buffer) Flush() {
+	b.mu.Lock()
+	var kept [][]Span
+	for len(b.order) > 0 {
+		kept = b.decideOldest(kept)
+	}
+	b.mu.Unlock()
+	b.export(kept)
+}
+
+// Close stops the Buffer and decides all buffered traces.
+func (b *Buffer) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.done)
+	b.stopped.Wait()
+	b.Flush()
+}
+
+// expire decides the traces that reached their timeout and forgets old
+// decisions.
+func (b *Buffer) expire() {
+	now := b.now()
+	b.mu.Lock()
+	var kept [][]Span
+	for len(b.order) > 0 && !now.Before(b.order[0].firstSeen.Add(b.opts.Timeout)) {
+		kept = b.decideOldest(kept)
+	}
+	for len(b.expiry) > 0 && !now.Before(b.expiry[0].at.Add(b.opts.Timeout)) {
+		delete(b.decided, b.expiry[0].id)
+		b.expiry = b.expiry[1:]
+	}
+	b.mu.Unlock()
+	b.export(kept)
+}
+
+// decideOldest runs the policies on the oldest buffered trace, removes it
+// from the buffer and appends its spans to `kept` if it is kept. The caller
+// MUST hold b.mu.
+func (b *Buffer) decideOldest(kept [][]Span) [][]Span {
+	trace := b.order[0]
+	b.order[0] = nil
+	b.order = b.order[1:]
+	delete(b.pending, trace.id)
+	b.bytes -= trace.bytes
+
+	keep := false
+	for _, policy := range b.opts.Policies {
+		if policy.Keep(trace.spans) {
+			keep = true
+			break
+		}
+	}
+	b.decided[trace.id] = keep
+	b.expiry = append(b.expiry, decision{id: trace.id, at: b.now()})
+	if keep {
+		kept = append(kept, trace.spans)
+	}
+	return kept
+}
+
+func (b *Buffer) export(kept [][]Span) {
+	for _, trace := range kept {
+		b.exporter.Export(trace)
+	}
+}
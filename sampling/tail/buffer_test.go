@@ -0,0 +1,189 @@
+package tail
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type collector struct {
+	sync.Mutex
+	traces [][]Span
+}
+
+func (c *collector) Export(trace []Span) {
+	c.Lock()
+	defer c.Unlock()
+	c.traces = append(c.traces, trace)
+}
+
+func (c *collector) operations() [][]string {
+	c.Lock()
+	defer c.Unlock()
+	var ops [][]string
+	for _, trace := range c.traces {
+		var names []string
+		for _, span := range trace {
+			names = append(names, span.OperationName)
+		}
+		ops = append(ops, names)
+	}
+	return ops
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func span(traceID, spanID, parentID, name string, tags map[string]interface{}) Span {
+	return Span{TraceID: traceID, SpanID: spanID, ParentID: parentID, OperationName: name, Tags: tags}
+}
+
+func TestBuffer_Timeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	out := &collector{}
+	b := newBuffer(out, Options{Policies: []Policy{AnyError()}, Timeout: time.Second}, clock.Now)
+
+	b.Add(span("1", "2", "1", "failed", map[string]interface{}{string(ext.Error): true}))
+	b.Add(span("5", "6", "", "ok", nil))
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	b.Add(span("1", "1", "", "root", nil))
+	b.Add(span("7", "7", "", "late", map[string]interface{}{string(ext.Error): true}))
+	b.expire()
+	assert.Empty(t, out.operations(), "traces are buffered until their timeout")
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	b.expire()
+	assert.Equal(t, [][]string{{"failed", "root"}}, out.operations())
+
+	b.Add(span("1", "3", "1", "straggler", nil))
+	b.Add(span("5", "8", "5", "dropped straggler", map[string]interface{}{string(ext.Error): true}))
+	assert.Equal(t, [][]string{{"failed", "root"}, {"straggler"}}, out.operations(),
+		"late spans follow the decision of their trace")
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	b.expire()
+	assert.Equal(t, [][]string{{"failed", "root"}, {"straggler"}, {"late"}}, out.operations())
+
+	clock.now = clock.now.Add(time.Second)
+	b.expire()
+	b.Add(span("1", "4", "1", "forgotten", nil))
+	b.Flush()
+	assert.Len(t, out.operations(), 3, "decisions are forgotten after another timeout")
+}
+
+func TestBuffer_MaxBytes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	out := &collector{}
+	keepAll := PolicyFunc(func([]Span) bool { return true })
+	b := newBuffer(out, Options{Policies: []Policy{keepAll}, MaxBytes: 250}, clock.Now)
+
+	first := span("1", "1", "", "first", nil)
+	first.Size = 100
+	b.Add(first)
+	second := span("2", "2", "", "second", nil)
+	second.Size = 100
+	b.Add(second)
+	assert.Empty(t, out.operations())
+	third := span("3", "3", "", "third", nil)
+	third.Size = 100
+	b.Add(third)
+	assert.Equal(t, [][]string{{"first"}}, out.operations(), "the oldest trace is decided early")
+	assert.Equal(t, 200, b.bytes)
+
+	b.Flush()
+	assert.Equal(t, [][]string{{"first"}, {"second"}, {"third"}}, out.operations())
+	assert.Zero(t, b.bytes)
+	assert.Greater(t, estimateSize(span("1", "1", "", "x", map[string]interface{}{"k": "v"})), 0)
+}
+
+func TestPolicies(t *testing.T) {
+	trace := []Span{
+		{SpanID: "1", OperationName: "root", Duration: time.Second},
+		{SpanID: "2", ParentID: "1", Duration: time.Minute, Tags: map[string]interface{}{
+			"http.status_code": uint16(503),
+			string(ext.Error):  false,
+		}},
+	}
+	assert.False(t, AnyError().Keep(trace))
+	assert.True(t, RootLatency(500*time.Millisecond).Keep(trace))
+	assert.False(t, RootLatency(time.Second).Keep(trace), "only the root span counts")
+	assert.False(t, RootLatency(0).Keep(trace[1:]))
+	assert.True(t, TagEquals("http.status_code", uint16(503)).Keep(trace))
+	assert.False(t, TagEquals("http.status_code", 503).Keep(trace))
+	assert.True(t, TagMatches("http.status_code", func(v interface{}) bool {
+		return v.(uint16) >= 500
+	}).Keep(trace))
+	assert.False(t, TagMatches("missing", func(interface{}) bool { return true }).Keep(trace))
+}
+
+func TestBuffer_BasicTracer(t *testing.T) {
+	recorder := basictracer.NewInMemoryRecorder()
+	b := New(RecorderExporter(recorder), Options{Policies: []Policy{AnyError()}})
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = b
+	opts.TraceID128Bit = true
+	tracer := basictracer.NewWithOptions(opts)
+
+	failed := tracer.StartSpan("failed")
+	child := tracer.StartSpan("child", ext.RPCServerOption(nil))
+	ext.Error.Set(child, true)
+	child.Finish()
+	failed.Finish()
+	tracer.StartSpan("ok").Finish()
+	b.Close()
+	b.Add(span("x", "x", "", "after close", map[string]interface{}{string(ext.Error): true}))
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "child", spans[0].Operation)
+
+	raw := spans[0]
+	raw.ParentSpanID = 7
+	converted := FromRawSpan(raw)
+	assert.Len(t, converted.TraceID, 32)
+	assert.Equal(t, "7", converted.ParentID)
+	assert.False(t, converted.IsRoot())
+	assert.Equal(t, raw, converted.Payload)
+}
+
+func TestBuffer_MockTracer(t *testing.T) {
+	tracer := mocktracer.New()
+	out := &collector{}
+	b := New(out, Options{Policies: []Policy{RootLatency(time.Minute)}, Timeout: 10 * time.Millisecond})
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	finished := tracer.SubscribeFinishedSpans(ctx)
+	go func() {
+		for s := range finished {
+			b.RecordMockSpan(s)
+		}
+	}()
+
+	start := time.Now()
+	slow := tracer.StartSpan("slow")
+	tracer.StartSpan("child", opentracing.ChildOf(slow.Context())).Finish()
+	slow.(*mocktracer.MockSpan).FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(time.Hour)})
+	tracer.StartSpan("fast").Finish()
+
+	require.Eventually(t, func() bool { return len(out.operations()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, [][]string{{"child", "slow"}}, out.operations())
+	out.Lock()
+	payload := out.traces[0][1].Payload
+	out.Unlock()
+	assert.Same(t, slow, payload)
+}
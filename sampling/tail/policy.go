@@ -0,0 +1,60 @@
+package tail
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// Policy decides whether a trace is kept, given all of its buffered spans.
+// A trace is kept if any of the Buffer's policies keeps it.
+type Policy interface {
+	Keep(trace []Span) bool
+}
+
+// PolicyFunc is a function adapter for Policy.
+type PolicyFunc func(trace []Span) bool
+
+// Keep belongs to the Policy interface.
+func (f PolicyFunc) Keep(trace []Span) bool {
+	return f(trace)
+}
+
+// AnyError keeps traces with at least one span tagged with ext.Error.
+func AnyError() Policy {
+	return TagEquals(string(ext.Error), true)
+}
+
+// RootLatency keeps traces whose root span took longer than `threshold`.
+// Traces whose root span is not buffered, e.g. because it was recorded by
+// another process, are not kept by this policy.
+func RootLatency(threshold time.Duration) Policy {
+	return PolicyFunc(func(trace []Span) bool {
+		for _, span := range trace {
+			if span.IsRoot() && span.Duration > threshold {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TagEquals keeps traces with at least one span with tag `key` set to
+// `value`.
+func TagEquals(key string, value interface{}) Policy {
+	return TagMatches(key, func(v interface{}) bool { return reflect.DeepEqual(v, value) })
+}
+
+// TagMatches keeps traces with at least one span with tag `key` for which
+// `match` returns true.
+func TagMatches(key string, match func(value interface{}) bool) Policy {
+	return PolicyFunc(func(trace []Span) bool {
+		for _, span := range trace {
+			if v, ok := span.Tags[key]; ok && match(v) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,116 @@
+// Package tail implements tail-based sampling: finished spans are buffered
+// per trace, and the decision to keep a trace is made from all of its spans
+// once the trace had time to complete.
+//
+// A Buffer accepts spans from the reference tracer, as its SpanRecorder:
+//
+//     buffer := tail.New(tail.RecorderExporter(recorder), tail.Options{
+//         Policies: []tail.Policy{tail.AnyError(), tail.RootLatency(time.Second)},
+//     })
+//     defer buffer.Close()
+//     opts := basictracer.DefaultOptions()
+//     opts.Recorder = buffer
+//
+// and from a MockTracer through RecordMockSpan(). Kept traces are forwarded
+// to an Exporter, dropped traces are discarded.
+package tail
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// Span is a finished span as seen by policies.
+type Span struct {
+	TraceID string
+	SpanID  string
+
+	// ParentID is empty for root spans.
+	ParentID string
+
+	OperationName string
+	StartTime     time.Time
+	Duration      time.Duration
+	Tags          map[string]interface{}
+
+	// Size is the estimated memory footprint of the span in bytes, counted
+	// against Options.MaxBytes. If zero, it is estimated from the fields
+	// above.
+	Size int
+
+	// Payload is the original span data, e.g. a basictracer.RawSpan or a
+	// *mocktracer.MockSpan, for the Exporter.
+	Payload interface{}
+}
+
+// IsRoot reports whether the span has no parent.
+func (s Span) IsRoot() bool {
+	return s.ParentID == ""
+}
+
+// FromRawSpan converts a span of the reference tracer. Its Payload is the
+// RawSpan.
+func FromRawSpan(raw basictracer.RawSpan) Span {
+	traceID := strconv.FormatUint(raw.Context.TraceID, 16)
+	if raw.Context.Is128Bit() {
+		traceID = fmt.Sprintf("%x%016x", raw.Context.TraceIDHigh, raw.Context.TraceID)
+	}
+	var parentID string
+	if raw.ParentSpanID != 0 {
+		parentID = strconv.FormatUint(raw.ParentSpanID, 16)
+	}
+	s := Span{
+		TraceID:       traceID,
+		SpanID:        strconv.FormatUint(raw.Context.SpanID, 16),
+		ParentID:      parentID,
+		OperationName: raw.Operation,
+		StartTime:     raw.Start,
+		Duration:      raw.Duration,
+		Tags:          raw.Tags,
+		Payload:       raw,
+	}
+	s.Size = estimateSize(s) + logsSize*len(raw.Logs)
+	return s
+}
+
+// FromMockSpan converts a finished MockSpan. Its Payload is the MockSpan.
+func FromMockSpan(span *mocktracer.MockSpan) Span {
+	var parentID string
+	if span.ParentID != 0 {
+		parentID = strconv.Itoa(span.ParentID)
+	}
+	s := Span{
+		TraceID:       strconv.Itoa(span.SpanContext.TraceID),
+		SpanID:        strconv.Itoa(span.SpanContext.SpanID),
+		ParentID:      parentID,
+		OperationName: span.OperationName,
+		StartTime:     span.StartTime,
+		Duration:      span.FinishTime.Sub(span.StartTime),
+		Tags:          span.Tags(),
+		Payload:       span,
+	}
+	s.Size = estimateSize(s) + logsSize*len(span.Logs())
+	return s
+}
+
+// Rough per-item sizes in bytes used to estimate the footprint of spans.
+const (
+	spanSize  = 256
+	fieldSize = 32
+	logsSize  = 128
+)
+
+func estimateSize(s Span) int {
+	size := spanSize + len(s.TraceID) + len(s.SpanID) + len(s.ParentID) + len(s.OperationName)
+	for k, v := range s.Tags {
+		size += fieldSize + len(k)
+		if str, ok := v.(string); ok {
+			size += len(str)
+		}
+	}
+	return size
+}
@@ -0,0 +1,14 @@
+// Package slogbridge writes span logs to log/slog, so that they show up in
+// local logs without a tracing backend.
+//
+// Tracer decorates any opentracing.Tracer and mirrors span logs and
+// finished spans to a slog.Handler, with trace and span IDs as attributes:
+//
+//     tracer := slogbridge.New(inner, slog.NewTextHandler(os.Stderr, nil))
+//
+// Encoder translates log.Field values into slog.Attr values, and can be
+// used on its own.
+//
+// log/slog needs Go 1.21, so the package is empty when built with an older
+// toolchain.
+package slogbridge
@@ -0,0 +1,86 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"log/slog"
+
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Encoder is a log.Encoder that collects the fields it is given as slog
+// attributes.
+type Encoder struct {
+	attrs []slog.Attr
+}
+
+// Attrs returns the attributes collected so far.
+func (e *Encoder) Attrs() []slog.Attr {
+	return e.attrs
+}
+
+// Attrs translates `fields` into slog attributes.
+func Attrs(fields ...log.Field) []slog.Attr {
+	e := &Encoder{attrs: make([]slog.Attr, 0, len(fields))}
+	for _, f := range fields {
+		f.Marshal(e)
+	}
+	return e.attrs
+}
+
+// EmitString belongs to the log.Encoder interface.
+func (e *Encoder) EmitString(key, value string) {
+	e.attrs = append(e.attrs, slog.String(key, value))
+}
+
+// EmitBool belongs to the log.Encoder interface.
+func (e *Encoder) EmitBool(key string, value bool) {
+	e.attrs = append(e.attrs, slog.Bool(key, value))
+}
+
+// EmitInt belongs to the log.Encoder interface.
+func (e *Encoder) EmitInt(key string, value int) {
+	e.attrs = append(e.attrs, slog.Int(key, value))
+}
+
+// EmitInt32 belongs to the log.Encoder interface.
+func (e *Encoder) EmitInt32(key string, value int32) {
+	e.attrs = append(e.attrs, slog.Int64(key, int64(value)))
+}
+
+// EmitInt64 belongs to the log.Encoder interface.
+func (e *Encoder) EmitInt64(key string, value int64) {
+	e.attrs = append(e.attrs, slog.Int64(key, value))
+}
+
+// EmitUint32 belongs to the log.Encoder interface.
+func (e *Encoder) EmitUint32(key string, value uint32) {
+	e.attrs = append(e.attrs, slog.Uint64(key, uint64(value)))
+}
+
+// EmitUint64 belongs to the log.Encoder interface.
+func (e *Encoder) EmitUint64(key string, value uint64) {
+	e.attrs = append(e.attrs, slog.Uint64(key, value))
+}
+
+// EmitFloat32 belongs to the log.Encoder interface.
+func (e *Encoder) EmitFloat32(key string, value float32) {
+	e.attrs = append(e.attrs, slog.Float64(key, float64(value)))
+}
+
+// EmitFloat64 belongs to the log.Encoder interface.
+func (e *Encoder) EmitFloat64(key string, value float64) {
+	e.attrs = append(e.attrs, slog.Float64(key, value))
+}
+
+// EmitObject belongs to the log.Encoder interface.
+func (e *Encoder) EmitObject(key string, value interface{}) {
+	e.attrs = append(e.attrs, slog.Any(key, value))
+}
+
+// EmitLazyLogger belongs to the log.Encoder interface. The fields emitted
+// by the LazyLogger are collected like any other.
+func (e *Encoder) EmitLazyLogger(value log.LazyLogger) {
+	value(e)
+}
@@ -0,0 +1,170 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// spanLogMessage is the message of span logs without an event or message
+// field.
+const spanLogMessage = "span log"
+
+// span mirrors logs and its finish to the Tracer's handler, and delegates
+// everything to the inner span.
+type span struct {
+	opentracing.Span
+	tracer *Tracer
+
+	sync.Mutex
+	name      string
+	tags      opentracing.Tags
+	startTime time.Time
+}
+
+func (s *span) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	s.name = operationName
+	s.Unlock()
+	s.Span.SetOperationName(operationName)
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	s.tags[key] = value
+	s.Unlock()
+	s.Span.SetTag(key, value)
+	return s
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.Span.SetBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	s.Span.LogFields(fields...)
+	s.mirror(time.Now(), s.Span.Context(), fields)
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	s.Span.LogKV(alternatingKeyValues...)
+	if fields, err := log.InterleavedKVToFields(alternatingKeyValues...); err == nil {
+		s.mirror(time.Now(), s.Span.Context(), fields)
+	}
+}
+
+func (s *span) LogEvent(event string) {
+	s.Span.LogEvent(event)
+	s.mirror(time.Now(), s.Span.Context(), []log.Field{log.String("event", event)})
+}
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	s.Span.LogEventWithPayload(event, payload)
+	s.mirror(time.Now(), s.Span.Context(), []log.Field{log.String("event", event), log.Object("payload", payload)})
+}
+
+func (s *span) Log(data opentracing.LogData) {
+	s.Span.Log(data)
+	lr := data.ToLogRecord()
+	s.mirror(lr.Timestamp, s.Span.Context(), lr.Fields)
+}
+
+// Finish and FinishWithOptions read the context before finishing the inner
+// span, which a tracer may recycle once it is finished.
+func (s *span) Finish() {
+	sc := s.Span.Context()
+	s.Span.Finish()
+	s.finished(time.Now(), sc)
+}
+
+func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
+	sc := s.Span.Context()
+	for _, lr := range opts.LogRecords {
+		s.mirror(lr.Timestamp, sc, lr.Fields)
+	}
+	for _, ld := range opts.BulkLogData {
+		lr := ld.ToLogRecord()
+		s.mirror(lr.Timestamp, sc, lr.Fields)
+	}
+	s.Span.FinishWithOptions(opts)
+	finishTime := opts.FinishTime
+	if finishTime.IsZero() {
+		finishTime = time.Now()
+	}
+	s.finished(finishTime, sc)
+}
+
+// mirror writes a span log. The "event" field, or else the "message"
+// field, becomes the message of the record. Logs of an error, i.e. with an
+// "error.object" field or the "error" event, are written at
+// slog.LevelError.
+func (s *span) mirror(ts time.Time, sc opentracing.SpanContext, fields []log.Field) {
+	level := s.tracer.opts.level
+	msg := ""
+	attrs := Attrs(fields...)
+	kept := attrs[:0]
+	for _, attr := range attrs {
+		switch {
+		case attr.Key == "error.object":
+			level = slog.LevelError
+		case attr.Key == "event" && attr.Value.String() == "error":
+			level = slog.LevelError
+		}
+		if msg == "" && (attr.Key == "event" || attr.Key == "message") && attr.Value.Kind() == slog.KindString {
+			msg = attr.Value.String()
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	if msg == "" {
+		msg = spanLogMessage
+	}
+	s.Lock()
+	name := s.name
+	s.Unlock()
+	kept = append(kept, slog.String(OperationKey, name))
+	s.tracer.write(ts, level, msg, sc, kept)
+}
+
+// finished writes the record of the finished span. Spans tagged with
+// ext.Error are written at slog.LevelError.
+func (s *span) finished(finishTime time.Time, sc opentracing.SpanContext) {
+	if !s.tracer.opts.logFinished {
+		return
+	}
+	level := s.tracer.opts.level
+	s.Lock()
+	name := s.name
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]interface{}, len(keys))
+	for i, k := range keys {
+		tags[i] = slog.Any(k, s.tags[k])
+	}
+	if isError, _ := s.tags[string(ext.Error)].(bool); isError {
+		level = slog.LevelError
+	}
+	s.Unlock()
+	s.tracer.write(finishTime, level, FinishedSpanMessage, sc, []slog.Attr{
+		slog.String(OperationKey, name),
+		slog.Duration(DurationKey, finishTime.Sub(s.startTime)),
+		slog.Group(TagsKey, tags...),
+	})
+}
@@ -0,0 +1,165 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Attribute keys of the records written by Tracer.
+const (
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+	OperationKey = "operation"
+	DurationKey  = "duration"
+	TagsKey      = "tags"
+)
+
+// FinishedSpanMessage is the message of the records of finished spans.
+const FinishedSpanMessage = "span finished"
+
+// Option configures a Tracer.
+type Option func(*options)
+
+type options struct {
+	level       slog.Level
+	logFinished bool
+	ids         func(opentracing.SpanContext) (traceID, spanID string, ok bool)
+}
+
+// Level sets the level of span logs and finished spans. Span logs with an
+// error, and spans tagged with ext.Error, are always written at
+// slog.LevelError. The default is slog.LevelInfo.
+func Level(level slog.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// LogFinishedSpans controls whether a record is written for every finished
+// span, with its operation name, duration and tags. Enabled by default.
+func LogFinishedSpans(enabled bool) Option {
+	return func(o *options) {
+		o.logFinished = enabled
+	}
+}
+
+// SpanIDs sets the function that returns the trace and span IDs of a span
+// context, for TraceIDKey and SpanIDKey. By default, IDs are taken from
+// contexts implementing SpanContextIDs.
+func SpanIDs(ids func(sc opentracing.SpanContext) (traceID, spanID string, ok bool)) Option {
+	return func(o *options) {
+		o.ids = ids
+	}
+}
+
+// SpanContextIDs is implemented by span contexts that expose their IDs, such
+// as those of basictracer and mocktracer.
+type SpanContextIDs interface {
+	TraceIDString() string
+	SpanIDString() string
+}
+
+// contextIDs returns the IDs of contexts implementing SpanContextIDs.
+func contextIDs(sc opentracing.SpanContext) (traceID, spanID string, ok bool) {
+	if c, ok := sc.(SpanContextIDs); ok {
+		return c.TraceIDString(), c.SpanIDString(), true
+	}
+	return "", "", false
+}
+
+// Tracer is an opentracing.Tracer that mirrors the logs and finished spans
+// of an inner tracer to a slog.Handler. Span contexts are those of the
+// inner tracer.
+type Tracer struct {
+	inner   opentracing.Tracer
+	handler slog.Handler
+	opts    options
+}
+
+// New returns a Tracer delegating to `inner` and writing to `handler`.
+func New(inner opentracing.Tracer, handler slog.Handler, opts ...Option) *Tracer {
+	t := &Tracer{
+		inner:   inner,
+		handler: handler,
+		opts: options{
+			level:       slog.LevelInfo,
+			logFinished: true,
+			ids:         contextIDs,
+		},
+	}
+	for _, o := range opts {
+		o(&t.opts)
+	}
+	return t
+}
+
+// Inner returns the inner tracer.
+func (t *Tracer) Inner() opentracing.Tracer {
+	return t.inner
+}
+
+// StartSpan belongs to the Tracer interface.
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	sso := opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&sso)
+	}
+	startTime := sso.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	tags := make(opentracing.Tags, len(sso.Tags))
+	for k, v := range sso.Tags {
+		tags[k] = v
+	}
+	return &span{
+		Span:      t.inner.StartSpan(operationName, opts...),
+		tracer:    t,
+		name:      operationName,
+		tags:      tags,
+		startTime: startTime,
+	}
+}
+
+// Inject belongs to the Tracer interface.
+func (t *Tracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	return t.inner.Inject(sc, format, carrier)
+}
+
+// Extract belongs to the Tracer interface.
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	return t.inner.Extract(format, carrier)
+}
+
+// ContextWithSpanHook implements opentracing.TracerContextWithSpanExtension
+// by calling the inner tracer's hook, if any, with the inner span.
+func (t *Tracer) ContextWithSpanHook(ctx context.Context, sp opentracing.Span) context.Context {
+	hook, ok := t.inner.(opentracing.TracerContextWithSpanExtension)
+	if !ok {
+		return ctx
+	}
+	if s, ok := sp.(*span); ok {
+		return hook.ContextWithSpanHook(ctx, s.Span)
+	}
+	return ctx
+}
+
+// write sends a record to the handler if it is enabled for `level`.
+func (t *Tracer) write(ts time.Time, level slog.Level, msg string, sc opentracing.SpanContext, attrs []slog.Attr) {
+	ctx := context.Background()
+	if !t.handler.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(ts, level, msg, 0)
+	if traceID, spanID, ok := t.opts.ids(sc); ok {
+		r.AddAttrs(slog.String(TraceIDKey, traceID), slog.String(SpanIDKey, spanID))
+	}
+	r.AddAttrs(attrs...)
+	t.handler.Handle(ctx, r)
+}
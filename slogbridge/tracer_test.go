@@ -0,0 +1,251 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/harness"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type recordingHandler struct {
+	sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.Lock()
+	defer h.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrs returns the attributes of the i-th record, with values resolved.
+func (h *recordingHandler) attrs(i int) map[string]interface{} {
+	h.Lock()
+	defer h.Unlock()
+	attrs := map[string]interface{}{}
+	h.records[i].Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() == slog.KindGroup {
+			group := map[string]interface{}{}
+			for _, ga := range a.Value.Group() {
+				group[ga.Key] = ga.Value.Any()
+			}
+			attrs[a.Key] = group
+		} else {
+			attrs[a.Key] = a.Value.Any()
+		}
+		return true
+	})
+	return attrs
+}
+
+type mockProbe struct{}
+
+func (mockProbe) SameTrace(first, second opentracing.Span) bool {
+	return first.Context().(mocktracer.MockSpanContext).TraceID ==
+		second.Context().(mocktracer.MockSpanContext).TraceID
+}
+
+func (mockProbe) SameSpanContext(span opentracing.Span, sc opentracing.SpanContext) bool {
+	mockCtx, ok := sc.(mocktracer.MockSpanContext)
+	if !ok {
+		return false
+	}
+	spanCtx := span.Context().(mocktracer.MockSpanContext)
+	return spanCtx.TraceID == mockCtx.TraceID && spanCtx.SpanID == mockCtx.SpanID
+}
+
+func TestAPICheck(t *testing.T) {
+	harness.RunAPIChecks(t, func() (tracer opentracing.Tracer, closer func()) {
+		return New(mocktracer.New(), &recordingHandler{}), nil
+	},
+		harness.CheckEverything(),
+		harness.UseProbe(mockProbe{}),
+	)
+}
+
+func TestAttrs(t *testing.T) {
+	var lazy log.LazyLogger = func(e log.Encoder) {
+		e.EmitString("lazy", "yes")
+	}
+	attrs := Attrs(
+		log.String("s", "v"),
+		log.Bool("b", true),
+		log.Int("i", -1),
+		log.Int32("i32", -2),
+		log.Int64("i64", -3),
+		log.Uint32("u32", 4),
+		log.Uint64("u64", 5),
+		log.Float32("f32", 0.5),
+		log.Float64("f64", 1.5),
+		log.Error(errors.New("boom")),
+		log.Object("o", []int{1}),
+		log.Lazy(lazy),
+		log.Noop(),
+	)
+	assert.Equal(t, []slog.Attr{
+		slog.String("s", "v"),
+		slog.Bool("b", true),
+		slog.Int("i", -1),
+		slog.Int64("i32", -2),
+		slog.Int64("i64", -3),
+		slog.Uint64("u32", 4),
+		slog.Uint64("u64", 5),
+		slog.Float64("f32", 0.5),
+		slog.Float64("f64", 1.5),
+		slog.String("error.object", "boom"),
+		slog.Any("o", []int{1}),
+		slog.String("lazy", "yes"),
+	}, attrs)
+}
+
+func TestTracer(t *testing.T) {
+	mock := mocktracer.New()
+	handler := &recordingHandler{}
+	tracer := New(mock, handler)
+
+	start := time.Now().Add(-time.Second)
+	sp := tracer.StartSpan("op", opentracing.StartTime(start), opentracing.Tag{Key: "k", Value: "v"})
+	assert.Same(t, tracer, sp.Tracer())
+	sp.SetOperationName("renamed").SetTag("n", 1)
+	sp.LogFields(log.Event("cache miss"), log.String("key", "x"))
+	sp.LogKV("message", "hello")
+	sp.LogFields(log.Error(errors.New("boom")))
+	ext.Error.Set(sp, true)
+	sp.Finish()
+
+	ctx := sp.Context().(mocktracer.MockSpanContext)
+	ids := map[string]interface{}{
+		TraceIDKey: strconv.Itoa(ctx.TraceID),
+		SpanIDKey:  strconv.Itoa(ctx.SpanID),
+	}
+	with := func(attrs map[string]interface{}) map[string]interface{} {
+		for k, v := range ids {
+			attrs[k] = v
+		}
+		return attrs
+	}
+
+	require.Len(t, handler.records, 4)
+	assert.Equal(t, "cache miss", handler.records[0].Message)
+	assert.Equal(t, slog.LevelInfo, handler.records[0].Level)
+	assert.Equal(t, with(map[string]interface{}{"key": "x", OperationKey: "renamed"}), handler.attrs(0))
+	assert.Equal(t, "hello", handler.records[1].Message)
+	assert.Equal(t, spanLogMessage, handler.records[2].Message)
+	assert.Equal(t, slog.LevelError, handler.records[2].Level)
+
+	finished := handler.records[3]
+	assert.Equal(t, FinishedSpanMessage, finished.Message)
+	assert.Equal(t, slog.LevelError, finished.Level)
+	attrs := handler.attrs(3)
+	assert.GreaterOrEqual(t, attrs[DurationKey], time.Second)
+	delete(attrs, DurationKey)
+	assert.Equal(t, with(map[string]interface{}{
+		OperationKey: "renamed",
+		TagsKey:      map[string]interface{}{"k": "v", "n": int64(1), string(ext.Error): true},
+	}), attrs)
+
+	spans := mock.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "renamed", spans[0].OperationName)
+	assert.Len(t, spans[0].Logs(), 3)
+}
+
+func TestTracer_FinishWithOptions(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelDebug}
+	tracer := New(mocktracer.New(), handler, Level(slog.LevelDebug), LogFinishedSpans(false))
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracer.StartSpan("op").FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: ts,
+		LogRecords: []opentracing.LogRecord{{Timestamp: ts, Fields: []log.Field{log.Event("late")}}},
+	})
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, "late", handler.records[0].Message)
+	assert.Equal(t, ts, handler.records[0].Time)
+	assert.Equal(t, slog.LevelDebug, handler.records[0].Level)
+}
+
+func TestTracer_DisabledLevel(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelWarn}
+	tracer := New(mocktracer.New(), handler)
+	sp := tracer.StartSpan("op")
+	sp.LogKV("event", "ignored")
+	sp.LogFields(log.Error(errors.New("boom")))
+	sp.Finish()
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, slog.LevelError, handler.records[0].Level)
+}
+
+func TestTracer_SpanIDs(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == DurationKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = basictracer.NewInMemoryRecorder()
+	tracer := New(basictracer.NewWithOptions(opts), handler)
+	sp := tracer.StartSpan("op")
+	sp.LogEvent("hello")
+	ctx := sp.Context().(basictracer.SpanContext)
+	assert.Equal(t,
+		"level=INFO msg=hello trace_id="+strconv.FormatUint(ctx.TraceID, 16)+
+			" span_id="+strconv.FormatUint(ctx.SpanID, 16)+" operation=op\n",
+		buf.String())
+
+	buf.Reset()
+	tracer = New(mocktracer.New(), handler, SpanIDs(func(opentracing.SpanContext) (string, string, bool) {
+		return "", "", false
+	}))
+	tracer.StartSpan("op").LogEvent("hello")
+	assert.Equal(t, "level=INFO msg=hello operation=op\n", buf.String())
+}
+
+func TestTracer_SpanPool(t *testing.T) {
+	handler := &recordingHandler{}
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = basictracer.NewInMemoryRecorder()
+	opts.EnableSpanPool = true
+	tracer := New(basictracer.NewWithOptions(opts), handler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracer.StartSpan("x").Finish()
+			tracer.StartSpan("y").FinishWithOptions(opentracing.FinishOptions{})
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, handler.records, 40)
+}
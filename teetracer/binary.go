@@ -0,0 +1,82 @@
+package teetracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// binaryMaxLen bounds the length of a frame so that corrupted input can't
+// trigger huge allocations.
+const binaryMaxLen = 1 << 20
+
+// injectBinary writes one frame per tracer: the length of the tracer's
+// output as a big-endian uint32, followed by the output. Tracers without a
+// context, or that fail to inject it, write an empty frame; the first such
+// failure is returned after all frames have been written.
+func (t *TeeTracer) injectBinary(sc SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(io.Writer)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	var firstErr error
+	frames := new(bytes.Buffer)
+	var length [4]byte
+	for i, tracer := range t.tracers {
+		var frame bytes.Buffer
+		if i < len(sc.contexts) && sc.contexts[i] != nil {
+			if err := tracer.Inject(sc.contexts[i], opentracing.Binary, &frame); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				frame.Reset()
+			}
+		}
+		binary.BigEndian.PutUint32(length[:], uint32(frame.Len()))
+		frames.Write(length[:])
+		frames.Write(frame.Bytes())
+	}
+	if _, err := writer.Write(frames.Bytes()); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// extractBinary reads the frames written by injectBinary and passes each
+// to its tracer.
+func (t *TeeTracer) extractBinary(carrier interface{}) (opentracing.SpanContext, error) {
+	reader, ok := carrier.(io.Reader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+	contexts := make([]opentracing.SpanContext, len(t.tracers))
+	errs := make([]error, len(t.tracers))
+	for i, tracer := range t.tracers {
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			if err != io.EOF {
+				return nil, opentracing.ErrSpanContextCorrupted
+			}
+			if i == 0 {
+				return nil, opentracing.ErrSpanContextNotFound
+			}
+			errs[i] = opentracing.ErrSpanContextNotFound
+			continue
+		}
+		if length > binaryMaxLen {
+			return nil, opentracing.ErrSpanContextCorrupted
+		}
+		if length == 0 {
+			errs[i] = opentracing.ErrSpanContextNotFound
+			continue
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return nil, opentracing.ErrSpanContextCorrupted
+		}
+		contexts[i], errs[i] = tracer.Extract(opentracing.Binary, bytes.NewReader(frame))
+	}
+	return merge(contexts, errs)
+}
@@ -0,0 +1,95 @@
+package teetracer
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// span forwards all calls to one span of each tracer.
+type span struct {
+	tracer *TeeTracer
+	spans  []opentracing.Span
+}
+
+func (s *span) Context() opentracing.SpanContext {
+	contexts := make([]opentracing.SpanContext, len(s.spans))
+	for i, sp := range s.spans {
+		contexts[i] = sp.Context()
+	}
+	return SpanContext{contexts: contexts}
+}
+
+func (s *span) Finish() {
+	for _, sp := range s.spans {
+		sp.Finish()
+	}
+}
+
+func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
+	for _, sp := range s.spans {
+		sp.FinishWithOptions(opts)
+	}
+}
+
+func (s *span) SetOperationName(operationName string) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetOperationName(operationName)
+	}
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetTag(key, value)
+	}
+	return s
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	for _, sp := range s.spans {
+		sp.LogFields(fields...)
+	}
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	for _, sp := range s.spans {
+		sp.LogKV(alternatingKeyValues...)
+	}
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetBaggageItem(restrictedKey, value)
+	}
+	return s
+}
+
+// BaggageItem reads the baggage of the first tracer's span.
+func (s *span) BaggageItem(restrictedKey string) string {
+	if len(s.spans) == 0 {
+		return ""
+	}
+	return s.spans[0].BaggageItem(restrictedKey)
+}
+
+func (s *span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *span) LogEvent(event string) {
+	for _, sp := range s.spans {
+		sp.LogEvent(event)
+	}
+}
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	for _, sp := range s.spans {
+		sp.LogEventWithPayload(event, payload)
+	}
+}
+
+func (s *span) Log(data opentracing.LogData) {
+	for _, sp := range s.spans {
+		sp.Log(data)
+	}
+}
@@ -0,0 +1,184 @@
+// Package teetracer provides a Tracer that fans spans out to several
+// tracers, e.g. to report to the old and the new backend during a vendor
+// migration:
+//
+//     tracer := teetracer.New(oldTracer, newTracer)
+//     opentracing.SetGlobalTracer(tracer)
+//
+// Every span started with a TeeTracer starts one span with each tracer and
+// forwards all calls to them. Inject writes the formats of all tracers into
+// the same carrier, and Extract gives each tracer back its own context.
+package teetracer
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// TeeTracer is an opentracing.Tracer that forwards to several tracers.
+type TeeTracer struct {
+	tracers []opentracing.Tracer
+}
+
+// New returns a TeeTracer forwarding to `tracers`. The first tracer is the
+// primary one: baggage is read from its spans.
+func New(tracers ...opentracing.Tracer) *TeeTracer {
+	return &TeeTracer{tracers: append([]opentracing.Tracer(nil), tracers...)}
+}
+
+// Tracers returns the tracers the TeeTracer forwards to.
+func (t *TeeTracer) Tracers() []opentracing.Tracer {
+	return append([]opentracing.Tracer(nil), t.tracers...)
+}
+
+// SpanContext is the SpanContext of a TeeTracer span: it holds the contexts
+// of all tracers, in the order of the tracers.
+type SpanContext struct {
+	contexts []opentracing.SpanContext
+}
+
+// Contexts returns the contexts of all tracers, in the order of the
+// tracers. A context is nil if the corresponding tracer had none, e.g.
+// because Extract did not find it in the carrier.
+func (c SpanContext) Contexts() []opentracing.SpanContext {
+	return append([]opentracing.SpanContext(nil), c.contexts...)
+}
+
+// ForeachBaggageItem belongs to the SpanContext interface. It iterates the
+// baggage of the first tracer that has a context.
+func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for _, sc := range c.contexts {
+		if sc != nil {
+			sc.ForeachBaggageItem(handler)
+			return
+		}
+	}
+}
+
+// StartSpan belongs to the Tracer interface.
+//
+// References to a SpanContext of the TeeTracer are resolved to the context
+// of each tracer. Other references are passed to all tracers as they are.
+func (t *TeeTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	sso := opentracing.StartSpanOptions{}
+	for _, o := range opts {
+		o.Apply(&sso)
+	}
+	spans := make([]opentracing.Span, len(t.tracers))
+	for i, tracer := range t.tracers {
+		spans[i] = tracer.StartSpan(operationName, startSpanOptions(sso, i)...)
+	}
+	return &span{tracer: t, spans: spans}
+}
+
+// startSpanOptions returns the options for the i-th tracer, keeping the
+// attributes of references. Tags are copied since tracers may modify them.
+func startSpanOptions(sso opentracing.StartSpanOptions, i int) []opentracing.StartSpanOption {
+	opts := make([]opentracing.StartSpanOption, 0, len(sso.References)+2)
+	for _, ref := range sso.AttributedReferences() {
+		if sc, ok := ref.ReferencedContext.(SpanContext); ok {
+			if i >= len(sc.contexts) || sc.contexts[i] == nil {
+				continue
+			}
+			ref.ReferencedContext = sc.contexts[i]
+		}
+		opts = append(opts, ref)
+	}
+	if len(sso.Tags) > 0 {
+		tags := make(opentracing.Tags, len(sso.Tags))
+		for k, v := range sso.Tags {
+			tags[k] = v
+		}
+		opts = append(opts, tags)
+	}
+	if !sso.StartTime.IsZero() {
+		opts = append(opts, opentracing.StartTime(sso.StartTime))
+	}
+	return opts
+}
+
+// Inject belongs to the Tracer interface.
+//
+// All tracers inject into the same carrier, which suits TextMap and
+// HTTPHeaders carriers as long as the tracers use distinct keys. For the
+// Binary format, the output of each tracer is written as a separate
+// length-prefixed frame. All tracers are attempted even if one fails; the
+// first error is returned.
+func (t *TeeTracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	teeContext, ok := sc.(SpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	if format == opentracing.Binary {
+		return t.injectBinary(teeContext, carrier)
+	}
+	var firstErr error
+	for i, tracer := range t.tracers {
+		if i >= len(teeContext.contexts) || teeContext.contexts[i] == nil {
+			continue
+		}
+		if err := tracer.Inject(teeContext.contexts[i], format, carrier); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Extract belongs to the Tracer interface.
+//
+// Every tracer extracts its own context. It fails only if none of them
+// succeeds, with opentracing.ErrSpanContextNotFound when every tracer
+// reported that error, and otherwise with the first other error.
+func (t *TeeTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format == opentracing.Binary {
+		return t.extractBinary(carrier)
+	}
+	contexts := make([]opentracing.SpanContext, len(t.tracers))
+	errs := make([]error, len(t.tracers))
+	for i, tracer := range t.tracers {
+		contexts[i], errs[i] = tracer.Extract(format, carrier)
+	}
+	return merge(contexts, errs)
+}
+
+// merge returns a SpanContext of the successfully extracted contexts, or an
+// error if there are none.
+func merge(contexts []opentracing.SpanContext, errs []error) (opentracing.SpanContext, error) {
+	var firstErr error
+	found := false
+	for i, err := range errs {
+		if err != nil {
+			contexts[i] = nil
+			if err != opentracing.ErrSpanContextNotFound && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if contexts[i] != nil {
+			found = true
+		}
+	}
+	if found {
+		return SpanContext{contexts: contexts}, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, opentracing.ErrSpanContextNotFound
+}
+
+// ContextWithSpanHook implements opentracing.TracerContextWithSpanExtension
+// by calling the hooks of the tracers that have one with their spans.
+func (t *TeeTracer) ContextWithSpanHook(ctx context.Context, sp opentracing.Span) context.Context {
+	s, ok := sp.(*span)
+	if !ok {
+		return ctx
+	}
+	for i, tracer := range t.tracers {
+		if hook, ok := tracer.(opentracing.TracerContextWithSpanExtension); ok {
+			ctx = hook.ContextWithSpanHook(ctx, s.spans[i])
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,186 @@
+package teetracer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/harness"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+type probe struct{}
+
+func mockContext(sc opentracing.SpanContext) mocktracer.MockSpanContext {
+	return sc.(SpanContext).Contexts()[0].(mocktracer.MockSpanContext)
+}
+
+func (probe) SameTrace(first, second opentracing.Span) bool {
+	return mockContext(first.Context()).TraceID == mockContext(second.Context()).TraceID
+}
+
+func (probe) SameSpanContext(span opentracing.Span, sc opentracing.SpanContext) bool {
+	if _, ok := sc.(SpanContext); !ok {
+		return false
+	}
+	return mockContext(span.Context()).SpanID == mockContext(sc).SpanID
+}
+
+func TestAPICheck(t *testing.T) {
+	harness.RunAPIChecks(t, func() (tracer opentracing.Tracer, closer func()) {
+		return New(mocktracer.New(), basictracer.New(basictracer.NewInMemoryRecorder())), nil
+	},
+		harness.CheckEverything(),
+		harness.UseProbe(probe{}),
+	)
+}
+
+func newTracers() (*TeeTracer, *mocktracer.MockTracer, *basictracer.InMemorySpanRecorder) {
+	mock := mocktracer.New()
+	recorder := basictracer.NewInMemoryRecorder()
+	return New(mock, basictracer.New(recorder)), mock, recorder
+}
+
+func TestTeeTracer_FanOut(t *testing.T) {
+	tracer, mock, recorder := newTracers()
+
+	parent := tracer.StartSpan("parent", opentracing.Tag{Key: "k", Value: "v"})
+	parent.SetBaggageItem("user", "alice")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	assert.Equal(t, "alice", child.BaggageItem("user"))
+	assert.Same(t, tracer, child.Tracer())
+	child.SetOperationName("renamed").SetTag("x", 1)
+	child.LogFields(log.String("event", "done"))
+	child.Finish()
+	parent.Finish()
+
+	mockSpans := mock.FinishedSpans()
+	require.Len(t, mockSpans, 2)
+	assert.Equal(t, "renamed", mockSpans[0].OperationName)
+	assert.Equal(t, mockSpans[1].SpanContext.SpanID, mockSpans[0].ParentID)
+	assert.Equal(t, 1, mockSpans[0].Tag("x"))
+	assert.Equal(t, "v", mockSpans[1].Tag("k"))
+	assert.Len(t, mockSpans[0].Logs(), 1)
+
+	rawSpans := recorder.GetSpans()
+	require.Len(t, rawSpans, 2)
+	assert.Equal(t, "renamed", rawSpans[0].Operation)
+	assert.Equal(t, rawSpans[1].Context.SpanID, rawSpans[0].ParentSpanID)
+	assert.Equal(t, opentracing.Tags{"x": 1}, rawSpans[0].Tags)
+	assert.Equal(t, opentracing.Tags{"k": "v"}, rawSpans[1].Tags)
+	assert.Len(t, rawSpans[0].Logs, 1)
+	assert.Equal(t, "alice", rawSpans[0].Context.Baggage["user"])
+}
+
+func TestTeeTracer_Propagation(t *testing.T) {
+	tracer, mock, recorder := newTracers()
+	for _, format := range []interface{}{opentracing.TextMap, opentracing.Binary} {
+		mock.Reset()
+		recorder.Reset()
+		parent := tracer.StartSpan("parent")
+		var carrier interface{} = opentracing.TextMapCarrier{}
+		if format == opentracing.Binary {
+			carrier = new(bytes.Buffer)
+		}
+		require.NoError(t, tracer.Inject(parent.Context(), format, carrier))
+		sc, err := tracer.Extract(format, carrier)
+		require.NoError(t, err)
+		tracer.StartSpan("child", opentracing.ChildOf(sc)).Finish()
+		parent.Finish()
+
+		mockSpans := mock.FinishedSpans()
+		require.Len(t, mockSpans, 2)
+		assert.Equal(t, mockSpans[1].SpanContext.SpanID, mockSpans[0].ParentID, "%v", format)
+		rawSpans := recorder.GetSpans()
+		require.Len(t, rawSpans, 2)
+		assert.Equal(t, rawSpans[1].Context.SpanID, rawSpans[0].ParentSpanID, "%v", format)
+	}
+}
+
+func TestTeeTracer_PartialExtract(t *testing.T) {
+	mock := mocktracer.New()
+	recorder := basictracer.NewInMemoryRecorder()
+	basic := basictracer.New(recorder)
+	upstream := basic.StartSpan("upstream")
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, basic.Inject(upstream.Context(), opentracing.TextMap, carrier))
+
+	tracer := New(mock, basic)
+	sc, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	contexts := sc.(SpanContext).Contexts()
+	assert.Nil(t, contexts[0])
+	assert.NotNil(t, contexts[1])
+
+	tracer.StartSpan("child", opentracing.ChildOf(sc)).Finish()
+	assert.Zero(t, mock.FinishedSpans()[0].ParentID, "the mock tracer starts a new trace")
+	assert.Equal(t, upstream.Context().(basictracer.SpanContext).SpanID, recorder.GetSpans()[0].ParentSpanID)
+
+	_, err = tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	_, err = tracer.Extract(opentracing.Binary, new(bytes.Buffer))
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	_, err = tracer.Extract(opentracing.Binary, bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+}
+
+type failingTracer struct {
+	opentracing.NoopTracer
+}
+
+var errInject = errors.New("inject failed")
+
+func (failingTracer) Inject(opentracing.SpanContext, interface{}, interface{}) error {
+	return errInject
+}
+
+func TestTeeTracer_PartialBinaryInject(t *testing.T) {
+	mock := mocktracer.New()
+	tracer := New(failingTracer{}, mock)
+	sp := tracer.StartSpan("x")
+	buf := new(bytes.Buffer)
+	assert.Equal(t, errInject, tracer.Inject(sp.Context(), opentracing.Binary, buf))
+
+	sc, err := tracer.Extract(opentracing.Binary, buf)
+	require.NoError(t, err)
+	contexts := sc.(SpanContext).Contexts()
+	assert.Nil(t, contexts[0])
+	assert.Equal(t, sp.Context().(SpanContext).Contexts()[1], contexts[1])
+}
+
+type hookTracer struct {
+	opentracing.NoopTracer
+}
+
+type hookKey struct{}
+
+func (hookTracer) ContextWithSpanHook(ctx context.Context, span opentracing.Span) context.Context {
+	return context.WithValue(ctx, hookKey{}, span)
+}
+
+func TestTeeTracer_ContextWithSpanHook(t *testing.T) {
+	tracer := New(mocktracer.New(), hookTracer{})
+	sp := tracer.StartSpan("x")
+	ctx := opentracing.ContextWithSpan(context.Background(), sp)
+	assert.Same(t, sp, opentracing.SpanFromContext(ctx))
+	assert.Equal(t, sp.(*span).spans[1], ctx.Value(hookKey{}))
+}
+
+func TestTeeTracer_ReferenceAttributes(t *testing.T) {
+	tracer, mock, _ := newTracers()
+	producer := tracer.StartSpan("producer")
+	tracer.StartSpan("consumer", opentracing.Link(producer.Context(), opentracing.Tags{"index": 1})).Finish()
+
+	refs := mock.FinishedSpans()[0].References()
+	require.Len(t, refs, 1)
+	assert.Equal(t, opentracing.LinkRef, refs[0].Type)
+	assert.Equal(t, producer.Context().(SpanContext).Contexts()[0], refs[0].ReferencedContext)
+	assert.Equal(t, opentracing.Tags{"index": 1}, refs[0].Attributes)
+}
@@ -144,6 +144,31 @@ type StartSpanOptions struct {
 	// If specified, the caller hands off ownership of Tags at
 	// StartSpan() invocation time.
 	Tags map[string]interface{}
+
+	// ReferenceAttributes holds key:value attributes describing the
+	// References: ReferenceAttributes[i], if present and non-nil, describes
+	// References[i]. It may be shorter than References, and is nil if no
+	// reference has attributes. See AttributedReference.
+	//
+	// Code that filters, reorders or rebuilds References must do the same
+	// to ReferenceAttributes, or the attributes end up on the wrong
+	// references. AttributedReferences() pairs both up for such code.
+	ReferenceAttributes []Tags
+}
+
+// AttributedReferences returns References along with their attributes.
+func (o StartSpanOptions) AttributedReferences() []AttributedReference {
+	if len(o.References) == 0 {
+		return nil
+	}
+	refs := make([]AttributedReference, len(o.References))
+	for i, ref := range o.References {
+		refs[i].SpanReference = ref
+		if i < len(o.ReferenceAttributes) {
+			refs[i].Attributes = o.ReferenceAttributes[i]
+		}
+	}
+	return refs
 }
 
 // StartSpanOption instances (zero or more) may be passed to Tracer.StartSpan.
@@ -204,6 +229,25 @@ const (
 	//
 	// See opentracing.FollowsFrom()
 	FollowsFromRef
+
+	// LinkRef refers to a Span that is related to the new Span without having
+	// caused it. For instance, a batch consumer may link to the Spans that
+	// produced each message of the batch, or a fan-in job may link to the
+	// Spans whose results it aggregates.
+	//
+	// A LinkRef Span is not a parent of the new Span: tracers must not derive
+	// the new Span's trace or parent from it, and a Span whose only
+	// references are LinkRefs is the root of its own trace. Tracers that do
+	// not support links may ignore LinkRefs.
+	//
+	// Tracers written before LinkRef existed commonly take References[0] as
+	// the parent without checking its type, and so treat a leading LinkRef
+	// as a parent. Pass links after any ChildOf or FollowsFrom reference, and
+	// expect such tracers to parent a Span to its first link when it has no
+	// other reference.
+	//
+	// See opentracing.Link()
+	LinkRef
 )
 
 // SpanReference is a StartSpanOption that pairs a SpanReferenceType and a
@@ -217,11 +261,27 @@ const (
 //
 // The `ChildOf(sc)` option above will not panic if sc == nil, it will just
 // not add the parent span reference to the options.
+//
+// References may carry attributes, see AttributedReference.
 type SpanReference struct {
 	Type              SpanReferenceType
 	ReferencedContext SpanContext
 }
 
+// WithAttributes returns an AttributedReference for the SpanReference with
+// the given attributes. For example:
+//
+//     span := tracer.StartSpan(
+//         "ProcessBatch",
+//         opentracing.FollowsFrom(msg.SpanContext).WithAttributes(
+//             opentracing.Tags{"message.index": i}))
+//
+// The restrictions on attribute values are identical to those for
+// Span.SetTag().
+func (r SpanReference) WithAttributes(attributes Tags) AttributedReference {
+	return AttributedReference{SpanReference: r, Attributes: attributes}
+}
+
 // Apply satisfies the StartSpanOption interface.
 func (r SpanReference) Apply(o *StartSpanOptions) {
 	if r.ReferencedContext != nil {
@@ -229,6 +289,33 @@ func (r SpanReference) Apply(o *StartSpanOptions) {
 	}
 }
 
+// AttributedReference is a StartSpanOption that adds a SpanReference along
+// with key:value attributes that describe it, e.g. why a batch consumer
+// refers to a message's Span.
+//
+// Tracers find the SpanReference in StartSpanOptions.References, as any
+// other, and its attributes in StartSpanOptions.ReferenceAttributes at the
+// same index. Tracers unaware of attributes are not affected.
+type AttributedReference struct {
+	SpanReference
+	Attributes Tags
+}
+
+// Apply satisfies the StartSpanOption interface.
+func (r AttributedReference) Apply(o *StartSpanOptions) {
+	if r.ReferencedContext == nil {
+		return
+	}
+	o.References = append(o.References, r.SpanReference)
+	if len(r.Attributes) == 0 {
+		return
+	}
+	for len(o.ReferenceAttributes) < len(o.References)-1 {
+		o.ReferenceAttributes = append(o.ReferenceAttributes, nil)
+	}
+	o.ReferenceAttributes = append(o.ReferenceAttributes, r.Attributes)
+}
+
 // ChildOf returns a StartSpanOption pointing to a dependent parent span.
 // If sc == nil, the option has no effect.
 //
@@ -252,6 +339,18 @@ func FollowsFrom(sc SpanContext) SpanReference {
 	}
 }
 
+// Link returns a StartSpanOption pointing to a Span that is related to the
+// new Span without having caused it, with optional attributes describing the
+// relationship. If sc == nil, the option has no effect.
+//
+// See LinkRef, AttributedReference
+func Link(sc SpanContext, attributes Tags) AttributedReference {
+	return SpanReference{
+		Type:              LinkRef,
+		ReferencedContext: sc,
+	}.WithAttributes(attributes)
+}
+
 // StartTime is a StartSpanOption that sets an explicit start timestamp for the
 // new Span.
 type StartTime time.Time